@@ -0,0 +1,364 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a single problem found when validating the attributes of an item or link
+type AttrViolation struct {
+	// the kind of entity validated (i.e. item or link)
+	Entity string
+	// the key of the item or link
+	Key string
+	// the name of the attribute
+	Attribute string
+	// the reason for the violation
+	Reason string
+}
+
+func (v AttrViolation) String() string {
+	return fmt.Sprintf("%s '%s' attribute '%s': %s", v.Entity, v.Key, v.Attribute, v.Reason)
+}
+
+// the error returned when one or more attributes are not valid
+type AttrValidationError struct {
+	Violations []AttrViolation
+}
+
+func (e *AttrValidationError) Error() string {
+	msg := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msg[i] = v.String()
+	}
+	return fmt.Sprintf("%d attribute violation(s) found: %s", len(e.Violations), strings.Join(msg, "; "))
+}
+
+// an attribute definition common to item and link types
+type attrDef struct {
+	Name     string
+	Type     string
+	DefValue string
+	Required bool
+	Regex    string
+}
+
+// validates item and link attributes against the attribute definitions of their types
+// definitions are retrieved from the Web API the first time a type is seen and then cached
+type AttrValidator struct {
+	client    *Client
+	lock      sync.Mutex
+	itemTypes map[string][]attrDef
+	linkTypes map[string][]attrDef
+}
+
+// creates a new attribute validator using the passed-in client to retrieve type attribute definitions
+// if the client is nil, only the types defined in the graph data being validated are known
+func NewAttrValidator(client *Client) *AttrValidator {
+	return &AttrValidator{
+		client:    client,
+		itemTypes: make(map[string][]attrDef),
+		linkTypes: make(map[string][]attrDef),
+	}
+}
+
+// clears the cached attribute definitions, so that they are reloaded next time they are needed
+func (v *AttrValidator) Reset() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.itemTypes = make(map[string][]attrDef)
+	v.linkTypes = make(map[string][]attrDef)
+}
+
+// validates the attributes of the item against the attributes defined by its item type
+// default values are set on the item for any missing optional attributes
+func (v *AttrValidator) ValidateItem(item *Item) error {
+	defs, err := v.itemTypeDefs(item.Type)
+	if err != nil {
+		return err
+	}
+	item.Attribute = applyDefaults(item.Attribute, defs)
+	return toAttrError(checkAttrs("item", item.Key, item.Attribute, defs))
+}
+
+// validates the attributes of the link against the attributes defined by its link type
+// default values are set on the link for any missing optional attributes
+func (v *AttrValidator) ValidateLink(link *Link) error {
+	defs, err := v.linkTypeDefs(link.Type)
+	if err != nil {
+		return err
+	}
+	link.Attribute = applyDefaults(link.Attribute, defs)
+	return toAttrError(checkAttrs("link", link.Key, link.Attribute, defs))
+}
+
+// validates the attributes of all items and links in the graph data
+// types defined in the graph data are validated against the attribute definitions in the graph data,
+// any other types are validated against the definitions held by the Web API
+func (v *AttrValidator) ValidateData(data *GraphData) error {
+	itemDefs := make(map[string][]attrDef)
+	for _, itemType := range data.ItemTypes {
+		itemDefs[itemType.Key] = []attrDef{}
+	}
+	for _, attr := range data.ItemTypeAttributes {
+		itemDefs[attr.ItemTypeKey] = append(itemDefs[attr.ItemTypeKey], itemAttrDef(attr))
+	}
+	linkDefs := make(map[string][]attrDef)
+	for _, linkType := range data.LinkTypes {
+		linkDefs[linkType.Key] = []attrDef{}
+	}
+	for _, attr := range data.LinkTypeAttribute {
+		linkDefs[attr.LinkTypeKey] = append(linkDefs[attr.LinkTypeKey], linkAttrDef(attr))
+	}
+	var violations []AttrViolation
+	for i := range data.Items {
+		item := &data.Items[i]
+		defs, ok := itemDefs[item.Type]
+		if !ok {
+			var err error
+			if defs, err = v.itemTypeDefs(item.Type); err != nil {
+				return err
+			}
+		}
+		item.Attribute = applyDefaults(item.Attribute, defs)
+		violations = append(violations, checkAttrs("item", item.Key, item.Attribute, defs)...)
+	}
+	for i := range data.Links {
+		link := &data.Links[i]
+		defs, ok := linkDefs[link.Type]
+		if !ok {
+			var err error
+			if defs, err = v.linkTypeDefs(link.Type); err != nil {
+				return err
+			}
+		}
+		link.Attribute = applyDefaults(link.Attribute, defs)
+		violations = append(violations, checkAttrs("link", link.Key, link.Attribute, defs)...)
+	}
+	return toAttrError(violations)
+}
+
+// gets the attribute definitions for an item type, from the cache if possible
+func (v *AttrValidator) itemTypeDefs(itemTypeKey string) ([]attrDef, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if defs, ok := v.itemTypes[itemTypeKey]; ok {
+		return defs, nil
+	}
+	// without a client, only the types defined in the graph data are known
+	if v.client == nil {
+		return nil, fmt.Errorf("item type '%s' is not defined", itemTypeKey)
+	}
+	list, err := v.client.GetItemTypeAttributes(&ItemType{Key: itemTypeKey})
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve attributes for item type '%s': %s", itemTypeKey, err)
+	}
+	defs := make([]attrDef, 0, len(list.Values))
+	for _, attr := range list.Values {
+		defs = append(defs, itemAttrDef(attr))
+	}
+	v.itemTypes[itemTypeKey] = defs
+	return defs, nil
+}
+
+// gets the attribute definitions for a link type, from the cache if possible
+func (v *AttrValidator) linkTypeDefs(linkTypeKey string) ([]attrDef, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if defs, ok := v.linkTypes[linkTypeKey]; ok {
+		return defs, nil
+	}
+	// without a client, only the types defined in the graph data are known
+	if v.client == nil {
+		return nil, fmt.Errorf("link type '%s' is not defined", linkTypeKey)
+	}
+	list, err := v.client.GetLinkTypeAttributes(&LinkType{Key: linkTypeKey})
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve attributes for link type '%s': %s", linkTypeKey, err)
+	}
+	defs := make([]attrDef, 0, len(list.Values))
+	for _, attr := range list.Values {
+		defs = append(defs, linkAttrDef(attr))
+	}
+	v.linkTypes[linkTypeKey] = defs
+	return defs, nil
+}
+
+func itemAttrDef(attr ItemTypeAttribute) attrDef {
+	return attrDef{Name: attr.Name, Type: attr.Type, DefValue: attr.DefValue, Required: attr.Required, Regex: attr.Regex}
+}
+
+func linkAttrDef(attr LinkTypeAttribute) attrDef {
+	return attrDef{Name: attr.Name, Type: attr.Type, DefValue: attr.DefValue, Required: attr.Required, Regex: attr.Regex}
+}
+
+func toAttrError(violations []AttrViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return &AttrValidationError{Violations: violations}
+}
+
+// sets the default value of any optional attribute missing in the passed-in attributes
+func applyDefaults(attrs map[string]interface{}, defs []attrDef) map[string]interface{} {
+	for _, def := range defs {
+		if def.Required || len(def.DefValue) == 0 {
+			continue
+		}
+		if _, exists := attrs[def.Name]; exists {
+			continue
+		}
+		if attrs == nil {
+			attrs = make(map[string]interface{})
+		}
+		value, err := parseAttrValue(def.Type, def.DefValue)
+		if err != nil {
+			// the default value does not match the declared type, use it as is
+			value = def.DefValue
+		}
+		attrs[def.Name] = value
+	}
+	return attrs
+}
+
+// checks the attributes against their definitions and returns all violations found
+func checkAttrs(entity, key string, attrs map[string]interface{}, defs []attrDef) []AttrViolation {
+	var violations []AttrViolation
+	add := func(name, reason string) {
+		violations = append(violations, AttrViolation{Entity: entity, Key: key, Attribute: name, Reason: reason})
+	}
+	known := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		known[def.Name] = true
+		value, exists := attrs[def.Name]
+		if !exists || value == nil {
+			if def.Required {
+				add(def.Name, "required attribute is missing")
+			}
+			continue
+		}
+		if err := checkAttrType(def.Type, value); err != nil {
+			add(def.Name, err.Error())
+			continue
+		}
+		if len(def.Regex) > 0 {
+			re, err := regexp.Compile(def.Regex)
+			if err != nil {
+				add(def.Name, fmt.Sprintf("invalid regex '%s' in attribute definition: %s", def.Regex, err))
+				continue
+			}
+			if !re.MatchString(fmt.Sprint(value)) {
+				add(def.Name, fmt.Sprintf("value '%v' does not match regex '%s'", value, def.Regex))
+			}
+		}
+	}
+	// reports unknown attributes in a predictable order
+	var names []string
+	for name := range attrs {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name, "attribute is not defined by the type")
+	}
+	return violations
+}
+
+// checks the value is of the declared attribute type
+// unknown or empty types are not checked
+func checkAttrType(attrType string, value interface{}) error {
+	switch strings.ToLower(attrType) {
+	case "string", "text":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("value '%v' is not a string", value)
+		}
+	case "integer", "int", "long":
+		if !isInteger(value) {
+			return fmt.Errorf("value '%v' is not an integer", value)
+		}
+	case "number", "float", "double", "decimal":
+		if !isNumber(value) {
+			return fmt.Errorf("value '%v' is not a number", value)
+		}
+	case "boolean", "bool":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("value '%v' is not a boolean", value)
+		}
+	case "date", "datetime", "timestamp", "time":
+		s, ok := value.(string)
+		if !ok {
+			if _, ok = value.(time.Time); ok {
+				return nil
+			}
+			return fmt.Errorf("value '%v' is not a date", value)
+		}
+		if _, err := ParseTimestamp(s); err != nil {
+			return fmt.Errorf("value '%v' is not a date", value)
+		}
+	}
+	return nil
+}
+
+// converts a string value (e.g. a default value) to the declared attribute type
+func parseAttrValue(attrType, value string) (interface{}, error) {
+	switch strings.ToLower(attrType) {
+	case "integer", "int", "long":
+		return strconv.ParseInt(value, 10, 64)
+	case "number", "float", "double", "decimal":
+		return strconv.ParseFloat(value, 64)
+	case "boolean", "bool":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
+
+func isInteger(value interface{}) bool {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	case float32:
+		return float64(v) == math.Trunc(float64(v))
+	case float64:
+		return v == math.Trunc(v)
+	case json.Number:
+		_, err := v.Int64()
+		return err == nil
+	}
+	return false
+}
+
+func isNumber(value interface{}) bool {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	case json.Number:
+		_, err := v.Float64()
+		return err == nil
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"strings"
+	"testing"
+)
+
+// validates graph data attributes without calling the Web API
+func TestAttrValidator_ValidateData(t *testing.T) {
+	data := &GraphData{
+		ItemTypes: []ItemType{{Key: "HOST", Name: "Host"}},
+		ItemTypeAttributes: []ItemTypeAttribute{
+			{Key: "HOST_CPU", Name: "cpu", Type: "integer", DefValue: "2", ItemTypeKey: "HOST"},
+			{Key: "HOST_IP", Name: "ip", Type: "string", Required: true, Regex: `^\d+\.\d+\.\d+\.\d+$`, ItemTypeKey: "HOST"},
+		},
+		Items: []Item{
+			{Key: "host_1", Name: "Host 1", Type: "HOST", Attribute: map[string]interface{}{"ip": "10.0.0.1"}},
+			{Key: "host_2", Name: "Host 2", Type: "HOST", Attribute: map[string]interface{}{"cpu": 1.5, "ip": "localhost", "ram": 4}},
+		},
+	}
+	err := NewAttrValidator(nil).ValidateData(data)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	verr, ok := err.(*AttrValidationError)
+	if !ok {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	// host_2: cpu not an integer, ip does not match regex, ram is unknown
+	if len(verr.Violations) != 3 {
+		t.Fatalf("expected 3 violations, got %d: %s", len(verr.Violations), err)
+	}
+	// host_1 should have got the default cpu value
+	if cpu := data.Items[0].Attribute["cpu"]; cpu != int64(2) {
+		t.Fatalf("expected default cpu value 2, got %v", cpu)
+	}
+}
+
+// checks that a validator without a client reports types not defined in the graph data
+func TestAttrValidator_UndefinedType(t *testing.T) {
+	v := NewAttrValidator(nil)
+	err := v.ValidateData(&GraphData{Items: []Item{{Key: "host_1", Type: "HOST"}}})
+	if err == nil || !strings.Contains(err.Error(), "item type 'HOST' is not defined") {
+		t.Fatalf("expected an undefined item type error, got: %v", err)
+	}
+	err = v.ValidateData(&GraphData{Links: []Link{{Key: "link_1", Type: "HOST_RACK"}}})
+	if err == nil || !strings.Contains(err.Error(), "link type 'HOST_RACK' is not defined") {
+		t.Fatalf("expected an undefined link type error, got: %v", err)
+	}
+	if err = v.ValidateItem(&Item{Key: "host_1", Type: "HOST"}); err == nil {
+		t.Fatal("expected an undefined item type error")
+	}
+}
@@ -0,0 +1,157 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the default number of concurrent requests in a bulk operation
+const defaultBulkWorkers = 4
+
+// what a bulk operation does when an entity fails
+type ErrorPolicy int
+
+const (
+	// carries on with the remaining entities
+	ContinueOnError ErrorPolicy = iota
+	// does not start any further requests, requests in flight are completed
+	StopOnError
+)
+
+// options for bulk operations
+type BulkOptions struct {
+	// the maximum number of concurrent requests, defaults to 4
+	Workers int
+	// the maximum number of requests per second, zero means no limit
+	RateLimit float64
+	// what to do when an entity fails, defaults to ContinueOnError
+	OnError ErrorPolicy
+}
+
+// the error returned by a bulk operation when one or more entities fail
+type BulkError struct {
+	// the errors by entity key
+	Errors map[string]error
+	// the number of entities not attempted because the operation stopped on error
+	Skipped int
+}
+
+func (e *BulkError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	msg := make([]string, len(keys))
+	for i, key := range keys {
+		msg[i] = fmt.Sprintf("'%s': %s", key, e.Errors[key])
+	}
+	s := fmt.Sprintf("%d entities failed: %s", len(keys), strings.Join(msg, "; "))
+	if e.Skipped > 0 {
+		s = fmt.Sprintf("%s; %d entities skipped", s, e.Skipped)
+	}
+	return s
+}
+
+// runs an operation on n entities using a bounded pool of workers
+// kind: the kind of entity, used in the results
+// key: gets the key of the i-th entity
+// op: performs the request for the i-th entity
+// returns the results by key, entities skipped after a failure have no result
+func runBulk(kind string, n int, key func(i int) string, op func(i int) (*Result, error), opts *BulkOptions) (map[string]EntityResult, error) {
+	if opts == nil {
+		opts = new(BulkOptions)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultBulkWorkers
+	}
+	var throttle <-chan time.Time
+	if opts.RateLimit > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.RateLimit))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		once     sync.Once
+		results  = make(map[string]EntityResult, n)
+		failures = make(map[string]error)
+		jobs     = make(chan int)
+		stop     = make(chan struct{})
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := op(i)
+				r := newEntityResult(kind, key(i), result, err)
+				mu.Lock()
+				results[r.Key] = r
+				if r.Failed() {
+					failures[r.Key] = entityError(r)
+				}
+				mu.Unlock()
+				if r.Failed() && opts.OnError == StopOnError {
+					once.Do(func() { close(stop) })
+				}
+			}
+		}()
+	}
+	sent := 0
+feed:
+	for ; sent < n; sent++ {
+		if throttle != nil {
+			select {
+			case <-throttle:
+			case <-stop:
+				break feed
+			}
+		}
+		select {
+		case jobs <- sent:
+		case <-stop:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if len(failures) > 0 {
+		return results, &BulkError{Errors: failures, Skipped: n - sent}
+	}
+	return results, nil
+}
+
+// the error of a failed entity result
+func entityError(r EntityResult) error {
+	if len(r.Error) > 0 {
+		return errors.New(r.Error)
+	}
+	return fmt.Errorf("%s '%s' is locked", r.Kind, r.Key)
+}
+
+// the key of a type attribute in bulk results, as attribute keys are only unique within their type
+func attrKey(typeKey, key string) string {
+	return fmt.Sprintf("%s/%s", typeKey, key)
+}
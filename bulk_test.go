@@ -0,0 +1,67 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// checks that a bulk operation carries on after a failure and reports the results by key
+func TestRunBulk_Continue(t *testing.T) {
+	keys := []string{"item_0", "item_1", "item_2", "item_3", "item_4"}
+	results, err := runBulk(KindItem, len(keys),
+		func(i int) string { return keys[i] },
+		func(i int) (*Result, error) {
+			if i == 2 {
+				return nil, errors.New("boom")
+			}
+			return &Result{Operation: OpInsert, Changed: true}, nil
+		},
+		&BulkOptions{Workers: 2, RateLimit: 1000})
+	if len(results) != len(keys) {
+		t.Fatalf("expected %d results, got %d", len(keys), len(results))
+	}
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) || len(bulkErr.Errors) != 1 || bulkErr.Errors["item_2"] == nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results["item_4"].Operation != OpInsert {
+		t.Fatalf("unexpected result: %+v", results["item_4"])
+	}
+}
+
+// checks that a bulk operation stops starting requests after a failure
+func TestRunBulk_Stop(t *testing.T) {
+	n := 100
+	results, err := runBulk(KindItem, n,
+		func(i int) string { return fmt.Sprintf("item_%d", i) },
+		func(i int) (*Result, error) {
+			if i == 0 {
+				return &Result{Error: true, Message: "invalid item"}, nil
+			}
+			return &Result{Operation: OpNone}, nil
+		},
+		&BulkOptions{Workers: 1, OnError: StopOnError})
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) || bulkErr.Skipped == 0 {
+		t.Fatalf("expected skipped entities, got: %v", err)
+	}
+	if len(results)+bulkErr.Skipped != n {
+		t.Fatalf("expected %d results and skipped entities, got %d + %d", n, len(results), bulkErr.Skipped)
+	}
+}
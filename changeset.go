@@ -0,0 +1,224 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// a set of changes applied in order as a unit
+// if a change fails, the changes already applied are rolled back by restoring the entities to their prior state
+// the rollback is compensating rather than atomic: other clients may see the intermediate states
+type ChangeSet struct {
+	client  *Client
+	changes []*change
+}
+
+// a change in a change set
+type change struct {
+	// ActionUpdate for puts, ActionDelete for deletions
+	action PlanAction
+	kind   string
+	key    string
+	entity interface{}
+	// the state of the entity before the change, nil if it did not exist
+	snapshot interface{}
+	// true if the change has been applied
+	applied bool
+}
+
+// a change that could not be rolled back
+type RollbackFailure struct {
+	// the kind of entity (e.g. item, linkType)
+	Kind string
+	// the entity key
+	Key string
+	// the compensating action that failed
+	Action PlanAction
+	// the reason the compensating action failed
+	Err error
+}
+
+func (f RollbackFailure) String() string {
+	return fmt.Sprintf("cannot %s %s '%s': %s", f.Action, f.Kind, f.Key, f.Err)
+}
+
+// the error returned when a change set cannot be applied
+type ChangeSetError struct {
+	// the kind of the entity whose change failed
+	Kind string
+	// the key of the entity whose change failed
+	Key string
+	// the error that caused the change set to fail
+	Err error
+	// the changes that could not be rolled back, empty if the rollback succeeded
+	Uncompensated []RollbackFailure
+}
+
+func (e *ChangeSetError) Error() string {
+	msg := fmt.Sprintf("change to %s '%s' failed: %s", e.Kind, e.Key, e.Err)
+	if len(e.Uncompensated) == 0 {
+		return fmt.Sprintf("%s; all changes rolled back", msg)
+	}
+	failures := make([]string, len(e.Uncompensated))
+	for i, f := range e.Uncompensated {
+		failures[i] = f.String()
+	}
+	return fmt.Sprintf("%s; %d change(s) could not be rolled back: %s", msg, len(e.Uncompensated), strings.Join(failures, "; "))
+}
+
+func (e *ChangeSetError) Unwrap() error {
+	return e.Err
+}
+
+// creates a new empty change set
+func (c *Client) NewChangeSet() *ChangeSet {
+	return &ChangeSet{client: c}
+}
+
+// records the creation or update of an entity (e.g. *Item, *LinkType)
+func (cs *ChangeSet) Put(entity interface{}) *ChangeSet {
+	return cs.add(ActionUpdate, entity)
+}
+
+// records the deletion of an entity
+func (cs *ChangeSet) Delete(entity interface{}) *ChangeSet {
+	return cs.add(ActionDelete, entity)
+}
+
+// the number of changes recorded
+func (cs *ChangeSet) Len() int {
+	return len(cs.changes)
+}
+
+func (cs *ChangeSet) add(action PlanAction, entity interface{}) *ChangeSet {
+	cs.changes = append(cs.changes, &change{action: action, kind: kindOf(entity), key: entityKey(entity), entity: entity})
+	return cs
+}
+
+// applies the changes in the order they were recorded
+// if a change fails, the changes already applied are rolled back in reverse order and a *ChangeSetError is returned
+func (cs *ChangeSet) Apply() error {
+	for _, ch := range cs.changes {
+		if len(ch.kind) == 0 {
+			return fmt.Errorf("unsupported entity %T", ch.entity)
+		}
+	}
+	for _, ch := range cs.changes {
+		if err := cs.apply(ch); err != nil {
+			return &ChangeSetError{Kind: ch.kind, Key: ch.key, Err: err, Uncompensated: cs.rollback()}
+		}
+	}
+	return nil
+}
+
+// takes a snapshot of the entity and applies the change
+func (cs *ChangeSet) apply(ch *change) error {
+	current, err := cs.client.getEntity(ch.entity)
+	if IsNotFound(err) {
+		ch.snapshot = nil
+	} else if err != nil {
+		return fmt.Errorf("cannot take a snapshot: %s", err)
+	} else {
+		ch.snapshot = current
+	}
+	if ch.action == ActionDelete {
+		if ch.snapshot == nil {
+			// nothing to delete
+			return nil
+		}
+		err = checkPut(cs.client.deleteEntity(ch.entity))
+	} else {
+		err = checkPut(cs.client.putEntity(ch.entity))
+	}
+	if err != nil {
+		return err
+	}
+	ch.applied = true
+	return nil
+}
+
+// undoes the applied changes in reverse order, returning the changes that could not be undone
+func (cs *ChangeSet) rollback() []RollbackFailure {
+	var failures []RollbackFailure
+	for i := len(cs.changes) - 1; i >= 0; i-- {
+		ch := cs.changes[i]
+		if !ch.applied {
+			continue
+		}
+		var (
+			action PlanAction
+			err    error
+		)
+		if ch.snapshot == nil {
+			// the entity was created by the change set
+			action = ActionDelete
+			err = checkPut(cs.client.deleteEntity(ch.entity))
+		} else {
+			// the entity existed: restores its prior state
+			action = ActionUpdate
+			if ch.action == ActionDelete {
+				action = ActionCreate
+			}
+			err = checkPut(cs.client.putEntity(restorable(ch.snapshot)))
+		}
+		if err != nil {
+			failures = append(failures, RollbackFailure{Kind: ch.kind, Key: ch.key, Action: action, Err: err})
+			continue
+		}
+		ch.applied = false
+	}
+	return failures
+}
+
+// gets a copy of a snapshot without its version, so that restoring it is not rejected as a stale update
+func restorable(snapshot interface{}) interface{} {
+	v := reflect.ValueOf(snapshot)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return snapshot
+	}
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	if f := c.Elem().FieldByName("Version"); f.IsValid() && f.CanSet() {
+		f.SetInt(0)
+	}
+	return c.Interface()
+}
+
+// gets the kind of an entity, or an empty string if the entity is not supported
+func kindOf(entity interface{}) string {
+	switch entity.(type) {
+	case *Model:
+		return KindModel
+	case *ItemType:
+		return KindItemType
+	case *ItemTypeAttribute:
+		return KindItemTypeAttribute
+	case *LinkType:
+		return KindLinkType
+	case *LinkTypeAttribute:
+		return KindLinkTypeAttribute
+	case *LinkRule:
+		return KindLinkRule
+	case *Item:
+		return KindItem
+	case *Link:
+		return KindLink
+	}
+	return ""
+}
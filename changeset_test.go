@@ -0,0 +1,138 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// a fake Web API holding items in memory, items with the key "bad" cannot be written
+// updates with a stale version are rejected and PATCH requests are not supported
+// PUT /data only writes the items in the graph data, and fails if any of them has the key "bad"
+func fakeItemServer() (*httptest.Server, map[string]Item) {
+	var mu sync.Mutex
+	items := make(map[string]Item)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPut && r.URL.Path == "/data" {
+			data := GraphData{}
+			_ = json.NewDecoder(r.Body).Decode(&data)
+			for _, item := range data.Items {
+				if item.Key == "bad" {
+					_ = json.NewEncoder(w).Encode(Result{Error: true, Message: "invalid item"})
+					return
+				}
+			}
+			for _, item := range data.Items {
+				items[item.Key] = item
+			}
+			_ = json.NewEncoder(w).Encode(Result{Changed: true})
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/item/")
+		switch r.Method {
+		case http.MethodGet:
+			item, ok := items[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(item)
+		case http.MethodPut:
+			if key == "bad" {
+				_ = json.NewEncoder(w).Encode(Result{Error: true, Message: "invalid item"})
+				return
+			}
+			item := Item{}
+			_ = json.NewDecoder(r.Body).Decode(&item)
+			current, exists := items[key]
+			// rejects updates based on a stale version
+			if exists && item.Version > 0 && item.Version != current.Version {
+				_ = json.NewEncoder(w).Encode(Result{Operation: OpLocked, Ref: key})
+				return
+			}
+			item.Version = current.Version + 1
+			items[key] = item
+			op := OpInsert
+			if exists {
+				op = OpUpdate
+			}
+			_ = json.NewEncoder(w).Encode(Result{Operation: op, Changed: true, Ref: key})
+		case http.MethodDelete:
+			delete(items, key)
+			_ = json.NewEncoder(w).Encode(Result{Operation: OpDelete, Changed: true, Ref: key})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return server, items
+}
+
+// checks that a failed change set restores the prior state
+func TestChangeSet_Rollback(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items["existing"] = Item{Key: "existing", Name: "Before", Version: 3}
+	items["removed"] = Item{Key: "removed", Name: "Removed"}
+	err = c.NewChangeSet().
+		Put(&Item{Key: "existing", Name: "After"}).
+		Put(&Item{Key: "created", Name: "Created"}).
+		Delete(&Item{Key: "removed"}).
+		Put(&Item{Key: "bad"}).
+		Apply()
+	csErr, ok := err.(*ChangeSetError)
+	if !ok {
+		t.Fatalf("expected a change set error, got: %v", err)
+	}
+	if csErr.Key != "bad" || len(csErr.Uncompensated) > 0 {
+		t.Fatalf("unexpected error: %v", csErr)
+	}
+	if items["existing"].Name != "Before" {
+		t.Fatalf("item 'existing' not restored: %+v", items["existing"])
+	}
+	if _, exists := items["created"]; exists {
+		t.Fatal("item 'created' not deleted")
+	}
+	if _, exists := items["removed"]; !exists {
+		t.Fatal("item 'removed' not restored")
+	}
+	// an update rejected as locked because of a stale version also rolls back the changes applied
+	err = c.NewChangeSet().
+		Put(&Item{Key: "created", Name: "Created"}).
+		Put(&Item{Key: "existing", Name: "Stale", Version: 1}).
+		Apply()
+	csErr, ok = err.(*ChangeSetError)
+	if !ok || csErr.Key != "existing" || !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected a version conflict on item 'existing', got: %v", err)
+	}
+	if _, exists := items["created"]; exists {
+		t.Fatal("item 'created' not deleted after a locked update")
+	}
+	if items["existing"].Name != "Before" {
+		t.Fatalf("item 'existing' changed by a locked update: %+v", items["existing"])
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 )
 
 const (
@@ -31,6 +32,7 @@ const (
 	PUT    = "PUT"
 	GET    = "GET"
 	POST   = "POST"
+	PATCH  = "PATCH"
 )
 
 // all entities interface for payload serialisation
@@ -52,15 +54,34 @@ func (s StringPayload) bytes() (*[]byte, error) {
 	return &b, nil
 }
 
+// a payload for an http operation already serialised
+type BytesPayload []byte
+
+func (b BytesPayload) reader() (*bytes.Reader, error) {
+	return bytes.NewReader(b), nil
+}
+
+func (b BytesPayload) bytes() (*[]byte, error) {
+	data := []byte(b)
+	return &data, nil
+}
+
 // modify the http request for example by adding any relevant http headers
 // payload is provided for example, in case a Content-MD5 header has to be added to the request
 type HttpRequestProcessor func(req *http.Request, payload Serializable) error
 
 // Onix HTTP client
 type Client struct {
-	conf  *ClientConf
-	self  *http.Client
-	token string
+	conf      *ClientConf
+	self      *http.Client
+	token     string
+	validator *AttrValidator
+	metaCheck *MetaValidator
+	// set to 1 when the Web API is found not to support PATCH requests
+	noPatch int32
+	// the status lifecycles by item type
+	lifecycles    map[string]*Lifecycle
+	lifecycleLock sync.RWMutex
 }
 
 // Result data retrieved by PUT and DELETE WAPI resources
@@ -98,6 +119,28 @@ func newResult(response *http.Response) (*Result, error) {
 	return result, nil
 }
 
+// the error returned when the Web API responds with an error status code
+type HttpError struct {
+	StatusCode int
+	Status     string
+	URL        string
+	// true if the error was returned by a GET request
+	get bool
+}
+
+func (e *HttpError) Error() string {
+	if e.get {
+		return fmt.Sprintf("error: response returned status: %s. resource: %s", e.Status, e.URL)
+	}
+	return fmt.Sprintf("error: response returned status: %s", e.Status)
+}
+
+// true if the error is an http 404 not found error
+func IsNotFound(err error) bool {
+	var httpErr *HttpError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
 // Response to an OAUth 2.0 token request
 type OAuthTokenResponse struct {
 	AccessToken string `json:"access_token"`
@@ -138,11 +181,26 @@ func NewClient(conf *ClientConf) (*Client, error) {
 			Timeout: conf.Timeout,
 		},
 	}
+	// if required, validates attributes before they are sent
+	if conf.ValidateAttributes {
+		client.validator = NewAttrValidator(client)
+	}
+	if conf.ValidateMeta {
+		client.metaCheck = NewMetaValidator(client)
+	}
 	return client, err
 }
 
 // Make a generic HTTP request
 func (c *Client) MakeRequest(method string, url string, payload Serializable, processor HttpRequestProcessor) (*http.Response, error) {
+	// serialises the payload only once, so that the request body and the processor (e.g. the Content-MD5 header) share the bytes
+	if payload != nil {
+		data, err := payload.bytes()
+		if err != nil {
+			return nil, err
+		}
+		payload = BytesPayload(*data)
+	}
 	// prepares the request body, if no body exists, a nil reader is retrieved
 	reader, err := c.getRequestBody(payload)
 	if err != nil {
@@ -172,7 +230,7 @@ func (c *Client) MakeRequest(method string, url string, payload Serializable, pr
 	}
 	// check for response status
 	if resp.StatusCode >= 300 {
-		err = errors.New(fmt.Sprintf("error: response returned status: %s", resp.Status))
+		err = &HttpError{StatusCode: resp.StatusCode, Status: resp.Status, URL: url}
 	}
 	return resp, err
 }
@@ -187,6 +245,11 @@ func (c *Client) Post(url string, payload Serializable, processor HttpRequestPro
 	return c.MakeRequest(POST, url, payload, processor)
 }
 
+// Make a PATCH HTTP request to the specified URL
+func (c *Client) Patch(url string, payload Serializable, processor HttpRequestProcessor) (*http.Response, error) {
+	return c.MakeRequest(PATCH, url, payload, processor)
+}
+
 // Make a DELETE HTTP request to the specified URL
 func (c *Client) Delete(url string, processor HttpRequestProcessor) (*http.Response, error) {
 	return c.MakeRequest(DELETE, url, nil, processor)
@@ -214,7 +277,7 @@ func (c *Client) Get(url string, processor HttpRequestProcessor) (*http.Response
 	}
 	// check error status codes
 	if resp.StatusCode != 200 {
-		err = errors.New(fmt.Sprintf("error: response returned status: %s. resource: %s", resp.Status, url))
+		err = &HttpError{StatusCode: resp.StatusCode, Status: resp.Status, URL: url, get: true}
 	}
 	return resp, err
 }
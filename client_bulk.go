@@ -0,0 +1,160 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+// creates or updates the models concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutModels(models []Model, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindModel, len(models),
+		func(i int) string { return models[i].Key },
+		func(i int) (*Result, error) { return c.PutModel(&models[i]) },
+		opts)
+}
+
+// deletes the models concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteModels(models []Model, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindModel, len(models),
+		func(i int) string { return models[i].Key },
+		func(i int) (*Result, error) { return c.DeleteModel(&models[i]) },
+		opts)
+}
+
+// creates or updates the item types concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutItemTypes(itemTypes []ItemType, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindItemType, len(itemTypes),
+		func(i int) string { return itemTypes[i].Key },
+		func(i int) (*Result, error) { return c.PutItemType(&itemTypes[i]) },
+		opts)
+}
+
+// deletes the item types concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteItemTypes(itemTypes []ItemType, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindItemType, len(itemTypes),
+		func(i int) string { return itemTypes[i].Key },
+		func(i int) (*Result, error) { return c.DeleteItemType(&itemTypes[i]) },
+		opts)
+}
+
+// creates or updates the item type attributes concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutItemTypeAttrs(attrs []ItemTypeAttribute, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindItemTypeAttribute, len(attrs),
+		func(i int) string { return attrKey(attrs[i].ItemTypeKey, attrs[i].Key) },
+		func(i int) (*Result, error) { return c.PutItemTypeAttr(&attrs[i]) },
+		opts)
+}
+
+// deletes the item type attributes concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteItemTypeAttrs(attrs []ItemTypeAttribute, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindItemTypeAttribute, len(attrs),
+		func(i int) string { return attrKey(attrs[i].ItemTypeKey, attrs[i].Key) },
+		func(i int) (*Result, error) { return c.DeleteItemTypeAttr(&attrs[i]) },
+		opts)
+}
+
+// creates or updates the link types concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutLinkTypes(linkTypes []LinkType, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLinkType, len(linkTypes),
+		func(i int) string { return linkTypes[i].Key },
+		func(i int) (*Result, error) { return c.PutLinkType(&linkTypes[i]) },
+		opts)
+}
+
+// deletes the link types concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteLinkTypes(linkTypes []LinkType, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLinkType, len(linkTypes),
+		func(i int) string { return linkTypes[i].Key },
+		func(i int) (*Result, error) { return c.DeleteLinkType(&linkTypes[i]) },
+		opts)
+}
+
+// creates or updates the link type attributes concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutLinkTypeAttrs(attrs []LinkTypeAttribute, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLinkTypeAttribute, len(attrs),
+		func(i int) string { return attrKey(attrs[i].LinkTypeKey, attrs[i].Key) },
+		func(i int) (*Result, error) { return c.PutLinkTypeAttr(&attrs[i]) },
+		opts)
+}
+
+// deletes the link type attributes concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteLinkTypeAttrs(attrs []LinkTypeAttribute, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLinkTypeAttribute, len(attrs),
+		func(i int) string { return attrKey(attrs[i].LinkTypeKey, attrs[i].Key) },
+		func(i int) (*Result, error) { return c.DeleteLinkTypeAttr(&attrs[i]) },
+		opts)
+}
+
+// creates or updates the link rules concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutLinkRules(linkRules []LinkRule, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLinkRule, len(linkRules),
+		func(i int) string { return linkRules[i].Key },
+		func(i int) (*Result, error) { return c.PutLinkRule(&linkRules[i]) },
+		opts)
+}
+
+// deletes the link rules concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteLinkRules(linkRules []LinkRule, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLinkRule, len(linkRules),
+		func(i int) string { return linkRules[i].Key },
+		func(i int) (*Result, error) { return c.DeleteLinkRule(&linkRules[i]) },
+		opts)
+}
+
+// creates or updates the items concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutItems(items []Item, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindItem, len(items),
+		func(i int) string { return items[i].Key },
+		func(i int) (*Result, error) { return c.PutItem(&items[i]) },
+		opts)
+}
+
+// deletes the items concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteItems(items []Item, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindItem, len(items),
+		func(i int) string { return items[i].Key },
+		func(i int) (*Result, error) { return c.DeleteItem(&items[i]) },
+		opts)
+}
+
+// creates or updates the links concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) PutLinks(links []Link, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLink, len(links),
+		func(i int) string { return links[i].Key },
+		func(i int) (*Result, error) { return c.PutLink(&links[i]) },
+		opts)
+}
+
+// deletes the links concurrently
+// returns the results by key and a *BulkError if any failed
+func (c *Client) DeleteLinks(links []Link, opts *BulkOptions) (map[string]EntityResult, error) {
+	return runBulk(KindLink, len(links),
+		func(i int) string { return links[i].Key },
+		func(i int) (*Result, error) { return c.DeleteLink(&links[i]) },
+		opts)
+}
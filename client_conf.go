@@ -61,6 +61,10 @@ type ClientConf struct {
 	AppSecret string
 	// time out
 	Timeout time.Duration
+	// if true, validates item and link attributes against the attributes defined by their types before they are sent
+	ValidateAttributes bool
+	// if true, validates item and link meta against the meta schema defined by their types before they are sent
+	ValidateMeta bool
 }
 
 // sets the AuthMode from a passed-in string
@@ -15,8 +15,16 @@
 */
 package oxc
 
+import (
+	"fmt"
+	"os"
+)
+
 // issue a Put http request with the GraphData as payload to the resource URI
 func (c *Client) PutData(data *GraphData) (*Result, error) {
+	if err := c.validateData(data); err != nil {
+		return nil, err
+	}
 	uri, err := data.uri(c.conf.BaseURI)
 	if err != nil {
 		return nil, err
@@ -24,3 +32,183 @@ func (c *Client) PutData(data *GraphData) (*Result, error) {
 	resp, err := c.Put(uri, data, c.addHttpHeaders)
 	return result(resp, err)
 }
+
+// validates the item and link attributes and meta in the graph data if the client is configured to do so
+func (c *Client) validateData(data *GraphData) error {
+	if c.validator != nil {
+		if err := c.validator.ValidateData(data); err != nil {
+			return err
+		}
+	}
+	if c.metaCheck != nil {
+		if err := c.metaCheck.ValidateData(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// issue a Put http request with the GraphData as payload and report the result for each entity
+// if the Web API does not report per entity results, or the bulk upload fails, the entities are sent individually
+// individual: if true, the entities are always sent individually, so that the exact operation on each entity is known
+func (c *Client) PutDataReport(data *GraphData, individual bool) (*DataReport, error) {
+	if err := c.validateData(data); err != nil {
+		return nil, err
+	}
+	report := new(DataReport)
+	entities := data.entities()
+	if !individual {
+		uri, err := data.uri(c.conf.BaseURI)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.Put(uri, data, c.addHttpHeaders)
+		if resp == nil {
+			return nil, err
+		}
+		bulk, decodeErr := decodeDataResult(resp)
+		if decodeErr == nil && len(bulk.Results) > 0 {
+			// the Web API reported the result for each entity
+			kinds := entityKinds(entities)
+			for i := range bulk.Results {
+				report.add(newEntityResult(kinds[bulk.Results[i].Ref], bulk.Results[i].Ref, &bulk.Results[i], nil))
+			}
+			return report, nil
+		}
+		if err == nil && decodeErr == nil && !bulk.Error {
+			// the upload succeeded but the operation on each entity is not known
+			for _, kind := range kindOrder {
+				for _, entity := range entities[kind] {
+					report.add(EntityResult{Kind: kind, Key: entityKey(entity), Operation: OpUnknown, Changed: bulk.Changed})
+				}
+			}
+			return report, nil
+		}
+		// the bulk upload failed: falls back to individual requests to find out which entities failed
+	}
+	report.Individual = true
+	for _, kind := range kindOrder {
+		for _, entity := range entities[kind] {
+			result, err := c.putEntity(entity)
+			report.add(newEntityResult(kind, entityKey(entity), result, err))
+		}
+	}
+	return report, nil
+}
+
+// uploads the GraphData in chunks of bounded size, in dependency order (meta-model, then items, then links)
+// if a checkpoint file is specified, an interrupted upload of the same data resumes after the last chunk uploaded
+// opts: upload options, can be nil
+func (c *Client) PutDataChunked(data *GraphData, opts *UploadOptions) error {
+	if opts == nil {
+		opts = new(UploadOptions)
+	}
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	maxBytes := opts.ChunkBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultChunkBytes
+	}
+	chunks, err := data.chunks(size, maxBytes)
+	if err != nil {
+		return err
+	}
+	cp := &uploadCheckpoint{ChunkSize: size, ChunkBytes: maxBytes}
+	if len(opts.Checkpoint) > 0 {
+		fingerprint, err := data.fingerprint()
+		if err != nil {
+			return err
+		}
+		saved, err := readCheckpoint(opts.Checkpoint)
+		if err != nil {
+			return fmt.Errorf("cannot read checkpoint file '%s': %s", opts.Checkpoint, err)
+		}
+		// only resumes if the checkpoint was recorded for the same data and chunk limits
+		if saved.Fingerprint == fingerprint && saved.ChunkSize == size && saved.ChunkBytes == maxBytes {
+			cp = saved
+		}
+		cp.Fingerprint = fingerprint
+	}
+	progress := UploadProgress{Chunks: len(chunks), TotalEntities: data.size()}
+	for i, chunk := range chunks {
+		progress.Chunk = i + 1
+		progress.Entities += chunk.size()
+		progress.Skipped = i < cp.Completed
+		if !progress.Skipped {
+			if err := checkPut(c.PutData(chunk)); err != nil {
+				return fmt.Errorf("cannot upload chunk %d of %d: %s", i+1, len(chunks), err)
+			}
+			cp.Completed = i + 1
+			if len(opts.Checkpoint) > 0 {
+				if err := cp.save(opts.Checkpoint); err != nil {
+					return fmt.Errorf("cannot write checkpoint file '%s': %s", opts.Checkpoint, err)
+				}
+			}
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+	}
+	// the upload is complete, so the checkpoint is no longer needed
+	if len(opts.Checkpoint) > 0 {
+		if err := os.Remove(opts.Checkpoint); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// exports the model with the specified key, its types, attributes and rules, and the items and links of its types
+// opts: options to filter the items and links exported, can be nil
+func (c *Client) ExportData(modelKey string, opts *ExportOptions) (*GraphData, error) {
+	if opts == nil {
+		opts = new(ExportOptions)
+	}
+	model, err := c.GetModel(&Model{Key: modelKey})
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve model '%s': %s", modelKey, err)
+	}
+	data, err := c.GetModelData(model)
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve data for model '%s': %s", modelKey, err)
+	}
+	data.Models = []Model{*model}
+	// the item types to export items for
+	itemTypes := make(map[string]bool)
+	for _, itemType := range opts.ItemTypes {
+		itemTypes[itemType] = true
+	}
+	filtered := len(opts.Partition) > 0 || len(opts.ItemTypes) > 0
+	// the keys of the exported items
+	items := make(map[string]bool)
+	for _, itemType := range data.ItemTypes {
+		if len(itemTypes) > 0 && !itemTypes[itemType.Key] {
+			continue
+		}
+		list, err := c.GetItemsByType(itemType.Key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot retrieve items of type '%s': %s", itemType.Key, err)
+		}
+		for _, item := range list.Values {
+			if len(opts.Partition) == 0 || item.Partition == opts.Partition {
+				data.Items = append(data.Items, item)
+				items[item.Key] = true
+			}
+		}
+	}
+	for _, linkType := range data.LinkTypes {
+		list, err := c.GetLinksByType(linkType.Key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot retrieve links of type '%s': %s", linkType.Key, err)
+		}
+		for _, link := range list.Values {
+			// if items are filtered, only exports the links between exported items
+			if !filtered || (items[link.StartItemKey] && items[link.EndItemKey]) {
+				data.Links = append(data.Links, link)
+			}
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,99 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// a fake Web API serving model "m" in partition p1 with item types A and B and link type L
+// items a1 and b1 are in partition p1, item a2 in partition p2, and links L join a1 and a2 to b1
+func fakeModelServer() *httptest.Server {
+	items := map[string][]Item{
+		"A": {{Key: "a1", Type: "A", Partition: "p1"}, {Key: "a2", Type: "A", Partition: "p2"}},
+		"B": {{Key: "b1", Type: "B", Partition: "p1"}},
+	}
+	links := []Link{
+		{Key: "a1->b1", Type: "L", StartItemKey: "a1", EndItemKey: "b1"},
+		{Key: "a2->b1", Type: "L", StartItemKey: "a2", EndItemKey: "b1"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/model/m":
+			_ = json.NewEncoder(w).Encode(Model{Key: "m", Name: "Model", Partition: "p1"})
+		case r.URL.Path == "/model/m/data":
+			_ = json.NewEncoder(w).Encode(GraphData{
+				ItemTypes: []ItemType{{Key: "A", Model: "m"}, {Key: "B", Model: "m"}},
+				LinkTypes: []LinkType{{Key: "L", Model: "m"}},
+				LinkRules: []LinkRule{{Key: "A->B", LinkTypeKey: "L", StartItemTypeKey: "A", EndItemTypeKey: "B"}},
+			})
+		case r.URL.Path == "/item":
+			_ = json.NewEncoder(w).Encode(ItemList{Values: items[r.URL.Query().Get("type")]})
+		case r.URL.Path == "/link" && r.URL.Query().Get("type") == "L":
+			_ = json.NewEncoder(w).Encode(LinkList{Values: links})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// checks the partition and item type filters of an export
+func TestClient_ExportData(t *testing.T) {
+	server := fakeModelServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		opts  *ExportOptions
+		items string
+		links string
+	}{
+		{nil, "[a1 a2 b1]", "[a1->b1 a2->b1]"},
+		// links are only exported if both ends are exported
+		{&ExportOptions{Partition: "p1"}, "[a1 b1]", "[a1->b1]"},
+		{&ExportOptions{Partition: "p2"}, "[a2]", "[]"},
+		{&ExportOptions{ItemTypes: []string{"A"}}, "[a1 a2]", "[]"},
+		{&ExportOptions{Partition: "p1", ItemTypes: []string{"B"}}, "[b1]", "[]"},
+	}
+	for i, tc := range cases {
+		data, err := c.ExportData("m", tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data.Models) != 1 || len(data.ItemTypes) != 2 || len(data.LinkTypes) != 1 || len(data.LinkRules) != 1 {
+			t.Fatalf("case %d: unexpected meta-model: %+v", i, data)
+		}
+		var items, links []string
+		for _, item := range data.Items {
+			items = append(items, item.Key)
+		}
+		for _, link := range data.Links {
+			links = append(links, link.Key)
+		}
+		if fmt.Sprint(items) != tc.items || fmt.Sprint(links) != tc.links {
+			t.Errorf("case %d: expected items %s and links %s, got %v and %v", i, tc.items, tc.links, items, links)
+		}
+	}
+	if _, err = c.ExportData("other", nil); err == nil {
+		t.Fatal("expected an error exporting a model that does not exist")
+	}
+}
@@ -15,13 +15,35 @@
 */
 package oxc
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
 
 // issue a Put http request with the Item data as payload to the resource URI
 func (c *Client) PutItem(item *Item) (*Result, error) {
 	if err := item.valid(); err != nil {
 		return nil, err
 	}
+	// validates the item attributes if the client is configured to do so
+	if c.validator != nil {
+		if err := c.validator.ValidateItem(item); err != nil {
+			return nil, err
+		}
+	}
+	// validates the item meta if the client is configured to do so
+	if c.metaCheck != nil {
+		if err := c.metaCheck.ValidateItem(item); err != nil {
+			return nil, err
+		}
+	}
+	// checks the status change is allowed if the item type has a lifecycle
+	if err := c.checkLifecycle(item); err != nil {
+		return nil, err
+	}
 	// gets the item URI
 	uri, err := item.uri(c.conf.BaseURI)
 	if err != nil {
@@ -31,6 +53,78 @@ func (c *Client) PutItem(item *Item) (*Result, error) {
 	return result(resp, err)
 }
 
+// updates the item merging our changes with any changes made by others since base was read
+// base: the item as it was read before making our changes
+// ours: the item with our changes
+// strategy: resolves the fields changed both by us and by others
+// returns the merged item and the result of putting it
+func (c *Client) PutItemMerged(base, ours *Item, strategy MergeStrategy) (*Item, *Result, error) {
+	theirs, err := c.GetItem(ours)
+	if IsNotFound(err) {
+		// nobody else has the item, there is nothing to merge
+		result, err := c.PutItem(ours)
+		return ours, result, err
+	} else if err != nil {
+		return nil, nil, err
+	}
+	merged, err := MergeItems(base, ours, theirs, strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := c.PutItem(merged)
+	return merged, result, err
+}
+
+// applies a partial update to the item with the specified key
+// if the Web API accepts PATCH requests, the patch is sent as an RFC 7386 merge patch
+// otherwise, the item is read, patched and written back, retrying if someone else changes it in the meantime
+func (c *Client) PatchItem(key string, patch *ItemPatch) (*Result, error) {
+	if patch.IsEmpty() {
+		return &Result{Operation: OpNone, Ref: key}, nil
+	}
+	// true if the PATCH request was rejected as if the Web API did not support it
+	rejected := false
+	// the patched item is read, checked and written back if status changes are checked against lifecycles,
+	// or if the client validates attributes or meta, as the Web API does not run these checks
+	checked := (patch.status != nil && c.hasLifecycles()) || c.validator != nil || c.metaCheck != nil
+	if doc, ok := patch.mergePatchDoc(); ok && !checked && atomic.LoadInt32(&c.noPatch) == 0 {
+		uri, err := (&Item{Key: key}).uri(c.conf.BaseURI)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := ToJson(doc)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.Patch(uri, BytesPayload(payload), c.addMergePatchHeaders)
+		var httpErr *HttpError
+		if !errors.As(err, &httpErr) || !patchUnsupported(httpErr.StatusCode) {
+			return result(resp, err)
+		}
+		rejected = true
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+	}
+	for attempt := 0; attempt < patchRetries; attempt++ {
+		item, err := c.GetItem(&Item{Key: key})
+		if err != nil {
+			return nil, err
+		}
+		if rejected {
+			// the item exists, so the Web API does not support patching: uses read-modify-write from now on
+			atomic.StoreInt32(&c.noPatch, 1)
+		}
+		patch.Apply(item)
+		// the version read is sent back, so that the Web API rejects the update if the item has changed since
+		result, err := c.PutItem(item)
+		if err != nil || result.Operation != OpLocked {
+			return result, err
+		}
+	}
+	return nil, fmt.Errorf("cannot patch item '%s': %w", key, ErrVersionConflict)
+}
+
 // issue a Delete http request to the resource URI
 func (c *Client) DeleteItem(item *Item) (*Result, error) {
 	uri, err := item.uri(c.conf.BaseURI)
@@ -60,6 +154,54 @@ func (c *Client) GetItem(item *Item) (*Item, error) {
 	return i, err
 }
 
+// get the versions of the item with the specified key, oldest first
+func (c *Client) GetItemHistory(key string) (*ItemHistory, error) {
+	uri, err := uriItemHistory(c.conf.BaseURI, key)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Get(uri, c.addHttpHeaders)
+	if err != nil {
+		return nil, err
+	}
+	history, err := decodeItemHistory(key, result)
+	defer func() {
+		if ferr := result.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+	return history, err
+}
+
+// get the item with the specified key as it was at the specified version
+func (c *Client) GetItemVersion(key string, version int64) (*Item, error) {
+	history, err := c.GetItemHistory(key)
+	if err != nil {
+		return nil, err
+	}
+	item := history.Version(version)
+	if item == nil {
+		return nil, fmt.Errorf("item '%s' has no version %d", key, version)
+	}
+	return item, nil
+}
+
+// get the item with the specified key as it was at the specified time
+func (c *Client) GetItemAsOf(key string, t time.Time) (*Item, error) {
+	history, err := c.GetItemHistory(key)
+	if err != nil {
+		return nil, err
+	}
+	item, err := history.AsOf(t)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("item '%s' did not exist at %s", key, t.Format(time.RFC3339))
+	}
+	return item, nil
+}
+
 // Get a list of items which are linked to the specified item
 func (c *Client) GetItemChildren(item *Item) (*ItemList, error) {
 	uri, err := item.uriItemChildren(c.conf.BaseURI)
@@ -159,3 +301,18 @@ func (c *Client) GetItemsOfType(itemType string) (*ItemList, error) {
 func (c *Client) uriItemsByType(baseUrl, itemType string) string {
 	return fmt.Sprintf("%s/item?type=%s", baseUrl, itemType)
 }
+
+// adds the http headers for an RFC 7386 merge patch request
+func (c *Client) addMergePatchHeaders(req *http.Request, payload Serializable) error {
+	if err := c.addHttpHeaders(req, payload); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	return nil
+}
+
+// true if the status code returned by a PATCH request means the Web API does not support patching
+// not found is included as the Web API returns it for unknown routes, a missing item is then reported by the fallback
+func patchUnsupported(statusCode int) bool {
+	return statusCode == http.StatusNotFound || statusCode == http.StatusMethodNotAllowed || statusCode == http.StatusNotImplemented
+}
@@ -55,3 +55,22 @@ func (c *Client) GetItemTypeAttr(typeAttr *ItemTypeAttribute) (*ItemTypeAttribut
 	}
 	return typeAttr.decode(result)
 }
+
+// get a list of the attributes defined for the specified item type
+func (c *Client) GetItemTypeAttributes(itemType *ItemType) (*ItemTypeAttributeList, error) {
+	uri, err := uriItemTypeAttributes(c.conf.BaseURI, itemType.Key)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Get(uri, c.addHttpHeaders)
+	if err != nil {
+		return nil, err
+	}
+	list, err := decodeItemTypeAttributeList(result)
+	defer func() {
+		if ferr := result.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+	return list, err
+}
@@ -0,0 +1,111 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import "fmt"
+
+// enforces a status lifecycle on the items of its type written by the client
+// a nil lifecycle removes the lifecycle of the item type
+func (c *Client) SetLifecycle(itemType string, lifecycle *Lifecycle) error {
+	if lifecycle != nil {
+		if lifecycle.err != nil {
+			return lifecycle.err
+		}
+		if lifecycle.itemType != itemType {
+			return fmt.Errorf("the lifecycle is for item type '%s' not '%s'", lifecycle.itemType, itemType)
+		}
+	}
+	c.lifecycleLock.Lock()
+	defer c.lifecycleLock.Unlock()
+	if lifecycle == nil {
+		delete(c.lifecycles, itemType)
+		return nil
+	}
+	if c.lifecycles == nil {
+		c.lifecycles = make(map[string]*Lifecycle)
+	}
+	c.lifecycles[itemType] = lifecycle
+	return nil
+}
+
+// the lifecycle of an item type, nil if it has none
+func (c *Client) Lifecycle(itemType string) *Lifecycle {
+	c.lifecycleLock.RLock()
+	defer c.lifecycleLock.RUnlock()
+	return c.lifecycles[itemType]
+}
+
+// moves an item to the named state of its type lifecycle
+func (c *Client) TransitionItem(key, state string) (*Result, error) {
+	item, err := c.GetItem(&Item{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	lifecycle := c.Lifecycle(item.Type)
+	if lifecycle == nil {
+		return nil, fmt.Errorf("item type '%s' has no lifecycle", item.Type)
+	}
+	status, ok := lifecycle.Status(state)
+	if !ok {
+		return nil, fmt.Errorf("state '%s' is not defined for item type '%s'", state, item.Type)
+	}
+	item.Status = status
+	return c.PutItem(item)
+}
+
+// get a list of the items of a type in the named state of its lifecycle
+func (c *Client) GetItemsInState(itemType, state string) (*ItemList, error) {
+	lifecycle := c.Lifecycle(itemType)
+	if lifecycle == nil {
+		return nil, fmt.Errorf("item type '%s' has no lifecycle", itemType)
+	}
+	status, ok := lifecycle.Status(state)
+	if !ok {
+		return nil, fmt.Errorf("state '%s' is not defined for item type '%s'", state, itemType)
+	}
+	return c.GetItems(&ItemQuery{Type: itemType, Filter: func(item *Item) bool { return item.Status == status }})
+}
+
+// the name of the state an item is in, empty if its type has no lifecycle or the status is not a state
+func (c *Client) ItemState(item *Item) string {
+	if lifecycle := c.Lifecycle(item.Type); lifecycle != nil {
+		name, _ := lifecycle.StateName(item.Status)
+		return name
+	}
+	return ""
+}
+
+// true if any lifecycle is enforced
+func (c *Client) hasLifecycles() bool {
+	c.lifecycleLock.RLock()
+	defer c.lifecycleLock.RUnlock()
+	return len(c.lifecycles) > 0
+}
+
+// checks an item against the lifecycle of its type, if any
+func (c *Client) checkLifecycle(item *Item) error {
+	lifecycle := c.Lifecycle(item.Type)
+	if lifecycle == nil {
+		return nil
+	}
+	current, err := c.GetItem(&Item{Key: item.Key})
+	if IsNotFound(err) {
+		current = nil
+	} else if err != nil {
+		return fmt.Errorf("cannot check the lifecycle of item '%s': %s", item.Key, err)
+	}
+	return lifecycle.Check(current, item)
+}
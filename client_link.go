@@ -15,11 +15,25 @@
 */
 package oxc
 
+import "fmt"
+
 // issue a Put http request with the Link data as payload to the resource URI
 func (c *Client) PutLink(link *Link) (*Result, error) {
 	if err := link.valid(); err != nil {
 		return nil, err
 	}
+	// validates the link attributes if the client is configured to do so
+	if c.validator != nil {
+		if err := c.validator.ValidateLink(link); err != nil {
+			return nil, err
+		}
+	}
+	// validates the link meta if the client is configured to do so
+	if c.metaCheck != nil {
+		if err := c.metaCheck.ValidateLink(link); err != nil {
+			return nil, err
+		}
+	}
 	uri, err := link.uri(c.conf.BaseURI)
 	if err != nil {
 		return nil, err
@@ -28,6 +42,28 @@ func (c *Client) PutLink(link *Link) (*Result, error) {
 	return result(resp, err)
 }
 
+// updates the link merging our changes with any changes made by others since base was read
+// base: the link as it was read before making our changes
+// ours: the link with our changes
+// strategy: resolves the fields changed both by us and by others
+// returns the merged link and the result of putting it
+func (c *Client) PutLinkMerged(base, ours *Link, strategy MergeStrategy) (*Link, *Result, error) {
+	theirs, err := c.GetLink(ours)
+	if IsNotFound(err) {
+		// nobody else has the link, there is nothing to merge
+		result, err := c.PutLink(ours)
+		return ours, result, err
+	} else if err != nil {
+		return nil, nil, err
+	}
+	merged, err := MergeLinks(base, ours, theirs, strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := c.PutLink(merged)
+	return merged, result, err
+}
+
 // issue a Delete http request to the resource URI
 func (c *Client) DeleteLink(link *Link) (*Result, error) {
 	uri, err := link.uri(c.conf.BaseURI)
@@ -50,3 +86,30 @@ func (c *Client) GetLink(link *Link) (*Link, error) {
 	}
 	return link.decode(result)
 }
+
+// get a list of the links of the specified link type
+func (c *Client) GetLinksByType(linkType string) (*LinkList, error) {
+	uri := c.uriLinksByType(c.conf.BaseURI, linkType)
+
+	// make an http Get request to the service
+	result, err := c.Get(uri, c.addHttpHeaders)
+
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := decodeLinkList(result)
+
+	defer func() {
+		if ferr := result.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+
+	return list, err
+}
+
+// uriLinksByType get the FQN for a list of links of a specified type
+func (c *Client) uriLinksByType(baseUrl, linkType string) string {
+	return fmt.Sprintf("%s/link?type=%s", baseUrl, linkType)
+}
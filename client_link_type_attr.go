@@ -53,3 +53,22 @@ func (c *Client) GetLinkTypeAttr(typeAttr *LinkTypeAttribute) (*LinkTypeAttribut
 	}
 	return typeAttr.decode(result)
 }
+
+// get a list of the attributes defined for the specified link type
+func (c *Client) GetLinkTypeAttributes(linkType *LinkType) (*LinkTypeAttributeList, error) {
+	uri, err := uriLinkTypeAttributes(c.conf.BaseURI, linkType.Key)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Get(uri, c.addHttpHeaders)
+	if err != nil {
+		return nil, err
+	}
+	list, err := decodeLinkTypeAttributeList(result)
+	defer func() {
+		if ferr := result.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+	return list, err
+}
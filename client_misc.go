@@ -16,6 +16,7 @@
 package oxc
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -39,3 +40,17 @@ func result(resp *http.Response, err error) (*Result, error) {
 	}
 	return nil, err
 }
+
+// turns a result flagged as an error, or locked because the entity version is stale, into an error
+func checkPut(result *Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if result != nil && result.Error {
+		return errors.New(result.Message)
+	}
+	if result != nil && result.Operation == OpLocked {
+		return fmt.Errorf("'%s' is locked: %w", result.Ref, ErrVersionConflict)
+	}
+	return nil
+}
@@ -61,3 +61,22 @@ func (c *Client) GetModel(model *Model) (*Model, error) {
 	}()
 	return m, err
 }
+
+// get the item types, item type attributes, link types, link type attributes and link rules defined by the model
+func (c *Client) GetModelData(model *Model) (*GraphData, error) {
+	uri, err := model.uriData(c.conf.BaseURI)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Get(uri, c.addHttpHeaders)
+	if err != nil {
+		return nil, err
+	}
+	data, err := new(GraphData).decode(result)
+	defer func() {
+		if ferr := result.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+	return data, err
+}
@@ -0,0 +1,210 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import "fmt"
+
+// works out the changes required to take the Web API from its current state to the desired state
+// desired: the graph data describing the desired state
+// opts: plan options, can be nil
+func (c *Client) Plan(desired *GraphData, opts *PlanOptions) (*Plan, error) {
+	if opts == nil {
+		opts = new(PlanOptions)
+	}
+	plan := new(Plan)
+	entities := desired.entities()
+	// the keys of the desired entities by kind
+	keys := make(map[string]map[string]bool)
+	for _, kind := range kindOrder {
+		keys[kind] = make(map[string]bool)
+		for _, entity := range entities[kind] {
+			key := entityKey(entity)
+			keys[kind][key] = true
+			step := PlanStep{Kind: kind, Key: key, entity: entity}
+			current, err := c.getEntity(entity)
+			if IsNotFound(err) {
+				step.Action = ActionCreate
+			} else if err != nil {
+				return nil, fmt.Errorf("cannot retrieve %s '%s': %s", kind, key, err)
+			} else {
+				if step.Changes, err = changedFields(entity, current); err != nil {
+					return nil, err
+				}
+				step.Action = ActionUpdate
+				if len(step.Changes) == 0 {
+					step.Action = ActionNone
+				}
+			}
+			if step.Action != ActionNone || opts.IncludeNoOp {
+				plan.Steps = append(plan.Steps, step)
+			}
+		}
+	}
+	if !opts.Prune {
+		return plan, nil
+	}
+	models := opts.Models
+	if len(models) == 0 {
+		for _, model := range desired.Models {
+			models = append(models, model.Key)
+		}
+	}
+	current, err := c.scopeEntities(models, opts.Partition)
+	if err != nil {
+		return nil, err
+	}
+	// deletes in reverse dependency order
+	for i := len(kindOrder) - 1; i >= 0; i-- {
+		kind := kindOrder[i]
+		for _, entity := range current[kind] {
+			key := entityKey(entity)
+			if !keys[kind][key] {
+				plan.Steps = append(plan.Steps, PlanStep{Kind: kind, Key: key, Action: ActionDelete, entity: entity})
+			}
+		}
+	}
+	return plan, nil
+}
+
+// applies the plan steps in order, stopping at the first failure
+func (c *Client) Apply(plan *Plan) error {
+	for i, step := range plan.Steps {
+		var err error
+		switch step.Action {
+		case ActionCreate, ActionUpdate:
+			err = checkPut(c.putEntity(step.entity))
+		case ActionDelete:
+			err = checkPut(c.deleteEntity(step.entity))
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot %s %s '%s' (step %d of %d): %w", step.Action, step.Kind, step.Key, i+1, len(plan.Steps), err)
+		}
+	}
+	return nil
+}
+
+// gets the entities of the specified models currently held by the Web API
+// partition: if not empty, only includes models and items in the partition
+func (c *Client) scopeEntities(models []string, partition string) (map[string][]interface{}, error) {
+	scope := new(GraphData)
+	for _, key := range models {
+		model, err := c.GetModel(&Model{Key: key})
+		if IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot retrieve model '%s': %s", key, err)
+		}
+		if len(partition) > 0 && model.Partition != partition {
+			continue
+		}
+		// exports all the links of the model types, as links to items in other partitions are pruned too
+		data, err := c.ExportData(key, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(partition) > 0 {
+			items := data.Items[:0]
+			for _, item := range data.Items {
+				if item.Partition == partition {
+					items = append(items, item)
+				}
+			}
+			data.Items = items
+		}
+		scope.merge(data)
+	}
+	return scope.entities(), nil
+}
+
+// gets the current state of an entity
+func (c *Client) getEntity(entity interface{}) (interface{}, error) {
+	var (
+		current interface{}
+		err     error
+	)
+	switch e := entity.(type) {
+	case *Model:
+		current, err = c.GetModel(e)
+	case *ItemType:
+		current, err = c.GetItemType(e)
+	case *ItemTypeAttribute:
+		current, err = c.GetItemTypeAttr(e)
+	case *LinkType:
+		current, err = c.GetLinkType(e)
+	case *LinkTypeAttribute:
+		current, err = c.GetLinkTypeAttr(e)
+	case *LinkRule:
+		current, err = c.GetLinkRule(e)
+	case *Item:
+		current, err = c.GetItem(e)
+	case *Link:
+		current, err = c.GetLink(e)
+	default:
+		return nil, fmt.Errorf("unsupported entity %T", entity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// creates or updates an entity
+func (c *Client) putEntity(entity interface{}) (*Result, error) {
+	switch e := entity.(type) {
+	case *Model:
+		return c.PutModel(e)
+	case *ItemType:
+		return c.PutItemType(e)
+	case *ItemTypeAttribute:
+		return c.PutItemTypeAttr(e)
+	case *LinkType:
+		return c.PutLinkType(e)
+	case *LinkTypeAttribute:
+		return c.PutLinkTypeAttr(e)
+	case *LinkRule:
+		return c.PutLinkRule(e)
+	case *Item:
+		return c.PutItem(e)
+	case *Link:
+		return c.PutLink(e)
+	}
+	return nil, fmt.Errorf("unsupported entity %T", entity)
+}
+
+// deletes an entity
+func (c *Client) deleteEntity(entity interface{}) (*Result, error) {
+	switch e := entity.(type) {
+	case *Model:
+		return c.DeleteModel(e)
+	case *ItemType:
+		return c.DeleteItemType(e)
+	case *ItemTypeAttribute:
+		return c.DeleteItemTypeAttr(e)
+	case *LinkType:
+		return c.DeleteLinkType(e)
+	case *LinkTypeAttribute:
+		return c.DeleteLinkTypeAttr(e)
+	case *LinkRule:
+		return c.DeleteLinkRule(e)
+	case *Item:
+		return c.DeleteItem(e)
+	case *Link:
+		return c.DeleteLink(e)
+	}
+	return nil, fmt.Errorf("unsupported entity %T", entity)
+}
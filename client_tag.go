@@ -0,0 +1,133 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// get a list of the items matching the query
+func (c *Client) GetItems(query *ItemQuery) (*ItemList, error) {
+	if query == nil {
+		query = new(ItemQuery)
+	}
+	// make an http Get request to the service
+	result, err := c.Get(query.uri(c.conf.BaseURI), c.addHttpHeaders)
+
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := decodeItemList(result)
+
+	defer func() {
+		if ferr := result.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+
+	if err != nil {
+		return nil, err
+	}
+	selected := &ItemList{}
+	for i := range list.Values {
+		match, err := query.matches(&list.Values[i])
+		if err != nil {
+			return nil, fmt.Errorf("cannot select item '%s': %s", list.Values[i].Key, err)
+		}
+		if match {
+			selected.Values = append(selected.Values, list.Values[i])
+		}
+	}
+	return selected, err
+}
+
+// get a list of the items having all the specified tags
+func (c *Client) GetItemsByTag(tags ...string) (*ItemList, error) {
+	return c.GetItems(&ItemQuery{Tags: tags})
+}
+
+// get a list of the links having all the specified tags
+func (c *Client) GetLinksByTag(tags ...string) (*LinkList, error) {
+	uri := c.uriLinksByTag(c.conf.BaseURI, tags)
+
+	// make an http Get request to the service
+	result, err := c.Get(uri, c.addHttpHeaders)
+
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := decodeLinkList(result)
+
+	defer func() {
+		if ferr := result.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+
+	if err != nil {
+		return nil, err
+	}
+	// the Web API may ignore the tag criteria so links are filtered again
+	selected := &LinkList{}
+	for _, link := range list.Values {
+		if link.Tags().HasAll(tags...) {
+			selected.Values = append(selected.Values, link)
+		}
+	}
+	return selected, err
+}
+
+// adds tags to the items matching the query
+// returns the results by item key and a *BulkError if any item could not be tagged
+func (c *Client) TagItems(query *ItemQuery, opts *BulkOptions, tags ...string) (map[string]EntityResult, error) {
+	patch := NewItemPatch()
+	for _, tag := range tags {
+		patch.AddTag(tag)
+	}
+	return c.patchItems(query, patch, opts)
+}
+
+// removes tags from the items matching the query
+// returns the results by item key and a *BulkError if any item could not be untagged
+func (c *Client) UntagItems(query *ItemQuery, opts *BulkOptions, tags ...string) (map[string]EntityResult, error) {
+	patch := NewItemPatch()
+	for _, tag := range tags {
+		patch.RemoveTag(tag)
+	}
+	return c.patchItems(query, patch, opts)
+}
+
+// applies a patch to the items matching the query
+func (c *Client) patchItems(query *ItemQuery, patch *ItemPatch, opts *BulkOptions) (map[string]EntityResult, error) {
+	list, err := c.GetItems(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve the items to patch: %s", err)
+	}
+	items := list.Values
+	return runBulk(KindItem, len(items),
+		func(i int) string { return items[i].Key },
+		func(i int) (*Result, error) { return c.PatchItem(items[i].Key, patch) },
+		opts)
+}
+
+// uriLinksByTag get the FQN for a list of links having the specified tags
+func (c *Client) uriLinksByTag(baseUrl string, tags []string) string {
+	return fmt.Sprintf("%s/link?tag=%s", baseUrl, url.QueryEscape(strings.Join(tags, "|")))
+}
@@ -0,0 +1,359 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gatblau/oxc"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// the information required to generate a struct for an item type
+type genType struct {
+	GoName      string
+	Key         string
+	Name        string
+	Description string
+	Attrs       []genAttr
+	Links       []genLink
+}
+
+// the information required to generate a struct field for an attribute
+type genAttr struct {
+	GoName   string
+	Name     string
+	GoType   string
+	Required bool
+	Regex    string
+	// the name of the package variable holding the compiled regex
+	RegexVar string
+	// the condition that is true if a required attribute is missing
+	Missing string
+	// the condition that is true if the attribute is set, checked before matching the regex
+	Set string
+	// the expression giving the attribute value
+	Value string
+}
+
+// the information required to generate a helper for a link rule
+type genLink struct {
+	Func         string
+	LinkTypeVar  string
+	EndGoName    string
+	RuleKey      string
+	StartType    string
+	EndType      string
+	LinkTypeName string
+}
+
+// the information required to generate a link type constant
+type genLinkType struct {
+	GoName      string
+	Key         string
+	Description string
+}
+
+type genModel struct {
+	Package   string
+	Types     []genType
+	LinkTypes []genLinkType
+	NeedsTime bool
+	NeedsFmt  bool
+	NeedsRe   bool
+}
+
+// generates the go source code for the types in the graph data
+func generate(pkg string, data *oxc.GraphData) ([]byte, error) {
+	model := genModel{Package: pkg}
+	// the package level names already declared, as different keys can map to the same go name
+	declared := make(map[string]string)
+	declare := func(name, what string) error {
+		if other, exists := declared[name]; exists {
+			return fmt.Errorf("%s and %s both map to the go name %s", other, what, name)
+		}
+		declared[name] = what
+		return nil
+	}
+	linkTypes := make(map[string]string)
+	for _, lt := range data.LinkTypes {
+		goName := goName(lt.Key)
+		if strings.HasSuffix(goName, "Link") {
+			goName += "Type"
+		} else {
+			goName += "LinkType"
+		}
+		if err := declare(goName, fmt.Sprintf("link type '%s'", lt.Key)); err != nil {
+			return nil, err
+		}
+		linkTypes[lt.Key] = goName
+		model.LinkTypes = append(model.LinkTypes, genLinkType{GoName: goName, Key: lt.Key, Description: comment(lt.Description)})
+	}
+	itemTypes := make(map[string]string)
+	for _, it := range data.ItemTypes {
+		itemTypes[it.Key] = goName(it.Key)
+		what := fmt.Sprintf("item type '%s'", it.Key)
+		if err := declare(itemTypes[it.Key], what); err != nil {
+			return nil, err
+		}
+		if err := declare(itemTypes[it.Key]+"Type", what); err != nil {
+			return nil, err
+		}
+	}
+	for _, it := range data.ItemTypes {
+		t := genType{GoName: itemTypes[it.Key], Key: it.Key, Name: it.Name, Description: comment(it.Description)}
+		// the go names already used by the generated struct fields and methods
+		fields := make(map[string]string)
+		for _, name := range builtinFields {
+			fields[name] = "a built-in field"
+		}
+		for _, attr := range data.ItemTypeAttributes {
+			if attr.ItemTypeKey != it.Key {
+				continue
+			}
+			a := newGenAttr(attr)
+			if name, exists := fields[a.GoName]; exists {
+				return nil, fmt.Errorf("attribute '%s' of item type '%s' maps to field %s which is already used by %s", attr.Name, it.Key, a.GoName, name)
+			}
+			fields[a.GoName] = fmt.Sprintf("attribute '%s'", attr.Name)
+			if len(attr.Regex) > 0 {
+				a.Regex = strconv.Quote(attr.Regex)
+				a.RegexVar = fmt.Sprintf("re%s%s", t.GoName, a.GoName)
+				if err := declare(a.RegexVar, fmt.Sprintf("the regex of attribute '%s' of item type '%s'", attr.Name, it.Key)); err != nil {
+					return nil, err
+				}
+				model.NeedsRe = true
+			}
+			if strings.HasSuffix(a.GoType, "time.Time") {
+				model.NeedsTime = true
+			}
+			// fmt is only used by the checks emitted in Validate
+			if len(a.Missing) > 0 || len(a.RegexVar) > 0 {
+				model.NeedsFmt = true
+			}
+			t.Attrs = append(t.Attrs, a)
+		}
+		sort.Slice(t.Attrs, func(i, j int) bool { return t.Attrs[i].Name < t.Attrs[j].Name })
+		for _, rule := range data.LinkRules {
+			endGoName, ok := itemTypes[rule.EndItemTypeKey]
+			if rule.StartItemTypeKey != it.Key || !ok {
+				continue
+			}
+			linkTypeVar, ok := linkTypes[rule.LinkTypeKey]
+			if !ok {
+				return nil, fmt.Errorf("link rule '%s' refers to link type '%s' which is not defined", rule.Key, rule.LinkTypeKey)
+			}
+			t.Links = append(t.Links, genLink{
+				Func:         fmt.Sprintf("LinkTo%sVia%s", endGoName, goName(rule.LinkTypeKey)),
+				LinkTypeVar:  linkTypeVar,
+				EndGoName:    endGoName,
+				RuleKey:      rule.Key,
+				StartType:    rule.StartItemTypeKey,
+				EndType:      rule.EndItemTypeKey,
+				LinkTypeName: rule.LinkTypeKey,
+			})
+		}
+		model.Types = append(model.Types, t)
+	}
+	sort.Slice(model.Types, func(i, j int) bool { return model.Types[i].Key < model.Types[j].Key })
+	sort.Slice(model.LinkTypes, func(i, j int) bool { return model.LinkTypes[i].Key < model.LinkTypes[j].Key })
+	buf := new(bytes.Buffer)
+	if err := codeTemplate.Execute(buf, model); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code is not valid: %s\n%s", err, buf.String())
+	}
+	return code, nil
+}
+
+// the names of the fields and methods every generated struct has
+var builtinFields = []string{"Key", "Name", "Description", "Status", "Partition", "Txt", "Tag", "Meta", "Item", "Validate"}
+
+// works out the field for an item type attribute
+// required attributes other than strings, lists and maps are pointers so that a missing value can be told from a zero value
+func newGenAttr(attr oxc.ItemTypeAttribute) genAttr {
+	a := genAttr{GoName: goName(attr.Name), Name: attr.Name, GoType: goType(attr.Type), Required: attr.Required}
+	// renames attributes that would clash with a built-in field or method (e.g. name -> NameAttr)
+	for _, name := range builtinFields {
+		if a.GoName == name {
+			a.GoName += "Attr"
+			break
+		}
+	}
+	field := "x." + a.GoName
+	a.Value = field
+	switch {
+	case a.GoType == "string" || strings.HasPrefix(a.GoType, "[]") || strings.HasPrefix(a.GoType, "map["):
+		a.Set = fmt.Sprintf("len(%s) > 0", field)
+		a.Missing = fmt.Sprintf("len(%s) == 0", field)
+	case a.Required:
+		a.GoType = "*" + a.GoType
+		a.Set = fmt.Sprintf("%s != nil", field)
+		a.Missing = fmt.Sprintf("%s == nil", field)
+		a.Value = "*" + field
+	}
+	if !a.Required {
+		a.Missing = ""
+	}
+	return a
+}
+
+// converts an Onix key or name into an exported go identifier (e.g. TF_STATE -> TfState)
+func goName(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		runes := []rune(part)
+		// keeps the case of camel case words, otherwise capitalises the word
+		if strings.ToUpper(part) == part || strings.ToLower(part) == part {
+			runes = []rune(strings.ToLower(part))
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	name := b.String()
+	if len(name) == 0 || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// maps an attribute type to a go type
+func goType(attrType string) string {
+	switch strings.ToLower(attrType) {
+	case "integer", "int", "long":
+		return "int64"
+	case "number", "float", "double", "decimal":
+		return "float64"
+	case "boolean", "bool":
+		return "bool"
+	case "date", "datetime", "timestamp", "time":
+		return "time.Time"
+	case "list", "array":
+		return "[]interface{}"
+	case "json", "map", "object":
+		return "map[string]interface{}"
+	default:
+		return "string"
+	}
+}
+
+// makes a description safe to use in a single line comment
+func comment(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by oxc-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	{{- if .NeedsFmt}}
+	"fmt"
+	{{- end}}
+	"github.com/gatblau/oxc"
+	{{- if .NeedsRe}}
+	"regexp"
+	{{- end}}
+	{{- if .NeedsTime}}
+	"time"
+	{{- end}}
+)
+
+// item type keys
+const (
+{{- range .Types}}
+	{{.GoName}}Type = "{{.Key}}"
+{{- end}}
+)
+{{if .LinkTypes}}
+// link type keys
+const (
+{{- range .LinkTypes}}
+	{{- if .Description}}
+	// {{.Description}}
+	{{- end}}
+	{{.GoName}} = "{{.Key}}"
+{{- end}}
+)
+{{end}}
+{{- range $t := .Types}}
+{{- range .Attrs}}{{if .RegexVar}}
+var {{.RegexVar}} = regexp.MustCompile({{.Regex}})
+{{- end}}{{end}}
+
+// {{.GoName}} is an item of type {{.Key}} ({{.Name}})
+{{- if .Description}}
+// {{.Description}}
+{{- end}}
+type {{.GoName}} struct {
+	_           struct{} ` + "`" + `oxc:"type:{{.Key}}"` + "`" + `
+	Key         string ` + "`" + `oxc:"key"` + "`" + `
+	Name        string ` + "`" + `oxc:"name"` + "`" + `
+	Description string ` + "`" + `oxc:"description"` + "`" + `
+	Status      int ` + "`" + `oxc:"status"` + "`" + `
+	Partition   string ` + "`" + `oxc:"partition"` + "`" + `
+	Txt         string ` + "`" + `oxc:"txt"` + "`" + `
+	Tag         []interface{} ` + "`" + `oxc:"tag"` + "`" + `
+	Meta        map[string]interface{} ` + "`" + `oxc:"meta"` + "`" + `
+{{- range .Attrs}}
+	{{.GoName}} {{.GoType}} ` + "`" + `oxc:"attr:{{.Name}}"` + "`" + `
+{{- end}}
+}
+
+// Item converts the {{.GoName}} into an item
+func (x *{{.GoName}}) Item() (*oxc.Item, error) {
+	return oxc.MarshalItem(x)
+}
+
+// Validate checks the required attributes are set and the attribute values match their regex
+func (x *{{.GoName}}) Validate() error {
+{{- range .Attrs}}
+{{- if .Missing}}
+	if {{.Missing}} {
+		return fmt.Errorf("{{$t.Key}} '%s': required attribute '{{.Name}}' is missing", x.Key)
+	}
+{{- end}}
+{{- if .RegexVar}}
+	if {{if .Set}}{{.Set}} && {{end}}!{{.RegexVar}}.MatchString(fmt.Sprint({{.Value}})) {
+		return fmt.Errorf("{{$t.Key}} '%s': attribute '{{.Name}}' does not match regex %s", x.Key, {{.RegexVar}})
+	}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{- range .Links}}
+
+// {{.Func}} creates a {{.LinkTypeName}} link from the {{.StartType}} to a {{.EndType}} as allowed by rule {{.RuleKey}}
+func (x *{{$t.GoName}}) {{.Func}}(end *{{.EndGoName}}) *oxc.Link {
+	return &oxc.Link{
+		Key:          oxc.LinkKey(x.Key, end.Key, {{.LinkTypeVar}}),
+		Type:         {{.LinkTypeVar}},
+		StartItemKey: x.Key,
+		EndItemKey:   end.Key,
+	}
+}
+{{- end}}
+{{end}}`))
@@ -0,0 +1,149 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"github.com/gatblau/oxc"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// regenerates the golden files: go test ./cmd/oxc-gen -update
+var update = flag.Bool("update", false, "update the golden files")
+
+// a model using every attribute type, attributes named after built-in fields and several links between the same types
+func testModel() *oxc.GraphData {
+	return &oxc.GraphData{
+		ItemTypes: []oxc.ItemType{
+			{Key: "HOST", Name: "Host", Description: "A virtual or physical host"},
+			{Key: "RACK", Name: "Rack"},
+		},
+		ItemTypeAttributes: []oxc.ItemTypeAttribute{
+			{Key: "HOST_CPU", Name: "cpu", Type: "integer", Required: true, ItemTypeKey: "HOST"},
+			{Key: "HOST_LOAD", Name: "load", Type: "number", Required: true, ItemTypeKey: "HOST"},
+			{Key: "HOST_ACTIVE", Name: "active", Type: "boolean", Required: true, ItemTypeKey: "HOST"},
+			{Key: "HOST_BOOTED", Name: "booted", Type: "timestamp", Required: true, ItemTypeKey: "HOST"},
+			{Key: "HOST_IP", Name: "ip", Type: "string", Required: true, Regex: `^\d+\.\d+\.\d+\.\d+$`, ItemTypeKey: "HOST"},
+			{Key: "HOST_PORTS", Name: "ports", Type: "list", Required: true, ItemTypeKey: "HOST"},
+			{Key: "HOST_NAME", Name: "name", Type: "string", ItemTypeKey: "HOST"},
+			{Key: "HOST_STATUS", Name: "status", Type: "integer", Regex: `^[0-9]$`, ItemTypeKey: "HOST"},
+			{Key: "RACK_UNITS", Name: "units", Type: "integer", Required: true, ItemTypeKey: "RACK"},
+		},
+		LinkTypes: []oxc.LinkType{
+			{Key: "HOSTED_IN", Description: "The rack the host is in"},
+			{Key: "BACKUP", Description: "The rack the host is backed up to"},
+		},
+		LinkRules: []oxc.LinkRule{
+			{Key: "HOST->RACK", LinkTypeKey: "HOSTED_IN", StartItemTypeKey: "HOST", EndItemTypeKey: "RACK"},
+			{Key: "HOST->RACK(BACKUP)", LinkTypeKey: "BACKUP", StartItemTypeKey: "HOST", EndItemTypeKey: "RACK"},
+		},
+	}
+}
+
+// checks the generated code matches the golden file and compiles
+func TestGenerate(t *testing.T) {
+	code, err := generate("model", testModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "model.go.golden")
+	if *update {
+		if err = ioutil.WriteFile(golden, code, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, expected) {
+		t.Fatalf("generated code does not match %s, run with -update to regenerate it:\n%s", golden, code)
+	}
+	compile(t, code)
+}
+
+// checks that a model with a single required integer attribute compiles
+func TestGenerate_RequiredInt(t *testing.T) {
+	code, err := generate("model", &oxc.GraphData{
+		ItemTypes:          []oxc.ItemType{{Key: "RACK", Name: "Rack"}},
+		ItemTypeAttributes: []oxc.ItemTypeAttribute{{Name: "units", Type: "integer", Required: true, ItemTypeKey: "RACK"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compile(t, code)
+}
+
+// checks that attributes mapping to the same field are rejected
+func TestGenerate_FieldClash(t *testing.T) {
+	_, err := generate("model", &oxc.GraphData{
+		ItemTypes: []oxc.ItemType{{Key: "HOST", Name: "Host"}},
+		ItemTypeAttributes: []oxc.ItemTypeAttribute{
+			{Name: "name", ItemTypeKey: "HOST"},
+			{Name: "NAME_ATTR", ItemTypeKey: "HOST"},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "NameAttr") {
+		t.Fatalf("expected a field clash error, got: %v", err)
+	}
+}
+
+// builds the generated code as a package of this module
+func compile(t *testing.T, code []byte) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool is not available")
+	}
+	dir, err := ioutil.TempDir("testdata", "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "model.go"), code, 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(goTool, "vet", "./"+filepath.ToSlash(dir)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated code does not compile: %s\n%s\n%s", err, out, code)
+	}
+}
+
+// checks that types mapping to the same package level name are rejected
+func TestGenerate_NameClash(t *testing.T) {
+	cases := []*oxc.GraphData{
+		{
+			ItemTypes: []oxc.ItemType{{Key: "HOST_LINK"}},
+			LinkTypes: []oxc.LinkType{{Key: "HOST"}},
+		},
+		{
+			ItemTypes: []oxc.ItemType{{Key: "HOST"}, {Key: "host"}},
+		},
+		{
+			LinkTypes: []oxc.LinkType{{Key: "HOSTED_IN"}, {Key: "hosted-in"}},
+		},
+	}
+	for i, data := range cases {
+		if _, err := generate("model", data); err == nil || !strings.Contains(err.Error(), "both map to the go name") {
+			t.Errorf("case %d: expected a name clash error, got: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+
+// oxc-gen generates strongly typed go structs from an Onix model
+// usage:
+//   oxc-gen -model TERRA -uri http://localhost:8080 -user admin -pwd 0n1x -pkg terra -out terra.go
+//   oxc-gen -file model.json -pkg terra -out terra.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/gatblau/oxc"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	var (
+		file  = flag.String("file", "", "the GraphData YAML or JSON file or directory containing the model (alternative to -uri)")
+		uri   = flag.String("uri", "", "the Onix Web API base URI")
+		user  = flag.String("user", "", "the Web API username")
+		pwd   = flag.String("pwd", "", "the Web API password")
+		model = flag.String("model", "", "the key of the model to generate types for (required with -uri)")
+		pkg   = flag.String("pkg", "model", "the package name of the generated code")
+		out   = flag.String("out", "", "the output file (defaults to stdout)")
+	)
+	flag.Parse()
+	data, err := loadData(*file, *uri, *user, *pwd, *model)
+	if err != nil {
+		exit(err)
+	}
+	code, err := generate(*pkg, data)
+	if err != nil {
+		exit(err)
+	}
+	if len(*out) == 0 {
+		fmt.Print(string(code))
+		return
+	}
+	if err = ioutil.WriteFile(*out, code, 0644); err != nil {
+		exit(err)
+	}
+}
+
+// loads the model definition from a file or from the Web API
+func loadData(file, uri, user, pwd, model string) (*oxc.GraphData, error) {
+	if len(file) > 0 {
+		return oxc.LoadData(file)
+	}
+	if len(uri) == 0 || len(model) == 0 {
+		return nil, fmt.Errorf("either -file or both -uri and -model must be provided")
+	}
+	conf := &oxc.ClientConf{BaseURI: uri, Username: user, Password: pwd}
+	if len(user) > 0 {
+		conf.AuthMode = oxc.Basic
+	}
+	client, err := oxc.NewClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetModelData(&oxc.Model{Key: model})
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "oxc-gen: %s\n", err)
+	os.Exit(1)
+}
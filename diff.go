@@ -0,0 +1,310 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// options to control how GraphData is compared
+type DiffOptions struct {
+	// if true, the fields managed by the Web API (version, created, updated, changedBy, encKeyIx) are compared
+	IncludeServerFields bool
+}
+
+// a change to a field of an entity
+type FieldChange struct {
+	// the JSON pointer of the field within the entity (e.g. /attribute/cpu)
+	Path string `json:"path"`
+	// the value in the source data, nil if the field was added
+	From interface{} `json:"from"`
+	// the value in the target data, nil if the field was removed
+	To interface{} `json:"to"`
+	// the JSON Patch operation: add, remove or replace
+	op string
+}
+
+// the differences in a single entity
+type EntityDiff struct {
+	// the kind of entity (e.g. item, linkType)
+	Kind string `json:"kind"`
+	// the entity key, for type attributes the type key and the attribute key separated by a slash
+	Key string `json:"key"`
+	// ActionCreate if the entity is only in the target data, ActionDelete if it is only in the source data
+	// or ActionUpdate if the entity is in both but its fields differ
+	Action PlanAction `json:"action"`
+	// the field changes of updated entities
+	Changes []FieldChange `json:"changes,omitempty"`
+	// the index of the entity in the source data, -1 for created entities
+	fromIndex int
+	// the target entity as a JSON value, for created entities
+	value interface{}
+}
+
+// the differences between two GraphData snapshots
+type DataDiff struct {
+	Entities []EntityDiff `json:"entities"`
+	// the kinds with no entities in the source data, whose lists may be null
+	empty map[string]bool
+}
+
+// an RFC 6902 JSON Patch operation
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// omits the value of remove operations, as false, zero or null are valid values for other operations
+func (op PatchOperation) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	type operation PatchOperation
+	return json.Marshal(operation(op))
+}
+
+// compares two GraphData snapshots, matching entities of the same kind by key
+// from: the source data
+// to: the target data
+// opts: diff options, can be nil
+func DiffData(from, to *GraphData, opts *DiffOptions) (*DataDiff, error) {
+	if opts == nil {
+		opts = new(DiffOptions)
+	}
+	diff := &DataDiff{empty: make(map[string]bool)}
+	fromEntities, toEntities := from.entities(), to.entities()
+	for _, kind := range kindOrder {
+		diff.empty[kind] = len(fromEntities[kind]) == 0
+		// the source entities by key, if a key is duplicated the first entity is used
+		index := make(map[string]int)
+		for i, entity := range fromEntities[kind] {
+			key := diffKey(entity)
+			if _, exists := index[key]; !exists {
+				index[key] = i
+			}
+		}
+		matched := make(map[string]bool)
+		for _, entity := range toEntities[kind] {
+			key := diffKey(entity)
+			if matched[key] {
+				continue
+			}
+			matched[key] = true
+			target, err := toFieldMap(entity)
+			if err != nil {
+				return nil, err
+			}
+			i, exists := index[key]
+			if !exists {
+				diff.Entities = append(diff.Entities, EntityDiff{Kind: kind, Key: key, Action: ActionCreate, fromIndex: -1, value: target})
+				continue
+			}
+			source, err := toFieldMap(fromEntities[kind][i])
+			if err != nil {
+				return nil, err
+			}
+			if !opts.IncludeServerFields {
+				for field := range serverFields {
+					delete(source, field)
+					delete(target, field)
+				}
+			}
+			changes := diffValues("", source, target, nil)
+			if len(changes) > 0 {
+				diff.Entities = append(diff.Entities, EntityDiff{Kind: kind, Key: key, Action: ActionUpdate, Changes: changes, fromIndex: i})
+			}
+		}
+		for key, i := range index {
+			if !matched[key] {
+				diff.Entities = append(diff.Entities, EntityDiff{Kind: kind, Key: key, Action: ActionDelete, fromIndex: i})
+			}
+		}
+	}
+	// keeps the kinds in dependency order and sorts the entities of each kind by key
+	order := make(map[string]int)
+	for i, kind := range kindOrder {
+		order[kind] = i
+	}
+	sort.SliceStable(diff.Entities, func(i, j int) bool {
+		a, b := diff.Entities[i], diff.Entities[j]
+		if a.Kind != b.Kind {
+			return order[a.Kind] < order[b.Kind]
+		}
+		return a.Key < b.Key
+	})
+	return diff, nil
+}
+
+// true if there are no differences
+func (d *DataDiff) IsEmpty() bool {
+	return len(d.Entities) == 0
+}
+
+// the number of entities for each action
+func (d *DataDiff) Summary() map[PlanAction]int {
+	summary := map[PlanAction]int{ActionCreate: 0, ActionUpdate: 0, ActionDelete: 0}
+	for _, e := range d.Entities {
+		summary[e.Action]++
+	}
+	return summary
+}
+
+// the RFC 6902 JSON Patch operations that turn the source data into the target data
+// field changes are applied first, then removals in descending index order so that the remaining indexes stay valid,
+// and finally additions at the end of each list
+func (d *DataDiff) Patch() []PatchOperation {
+	var (
+		ops       []PatchOperation
+		removals  = make(map[string][]int)
+		additions = make(map[string][]interface{})
+	)
+	for _, e := range d.Entities {
+		switch e.Action {
+		case ActionUpdate:
+			base := fmt.Sprintf("/%s/%d", kindFields[e.Kind], e.fromIndex)
+			for _, c := range e.Changes {
+				op := PatchOperation{Op: c.op, Path: base + c.Path}
+				if c.op != "remove" {
+					op.Value = c.To
+				}
+				ops = append(ops, op)
+			}
+		case ActionDelete:
+			removals[e.Kind] = append(removals[e.Kind], e.fromIndex)
+		case ActionCreate:
+			additions[e.Kind] = append(additions[e.Kind], e.value)
+		}
+	}
+	for i := len(kindOrder) - 1; i >= 0; i-- {
+		kind := kindOrder[i]
+		indexes := removals[kind]
+		sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+		for _, index := range indexes {
+			ops = append(ops, PatchOperation{Op: "remove", Path: fmt.Sprintf("/%s/%d", kindFields[kind], index)})
+		}
+	}
+	for _, kind := range kindOrder {
+		if len(additions[kind]) > 0 && d.empty[kind] {
+			// the list may be null in the source document, so it is added as a whole
+			ops = append(ops, PatchOperation{Op: "add", Path: "/" + kindFields[kind], Value: additions[kind]})
+			continue
+		}
+		for _, value := range additions[kind] {
+			ops = append(ops, PatchOperation{Op: "add", Path: fmt.Sprintf("/%s/-", kindFields[kind]), Value: value})
+		}
+	}
+	return ops
+}
+
+// the JSON Patch document that turns the source data into the target data
+func (d *DataDiff) JsonPatch() ([]byte, error) {
+	ops := d.Patch()
+	if ops == nil {
+		ops = []PatchOperation{}
+	}
+	return json.MarshalIndent(ops, "", "  ")
+}
+
+// the human readable report of the differences
+func (d *DataDiff) String() string {
+	var b strings.Builder
+	symbols := map[PlanAction]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}
+	for _, e := range d.Entities {
+		b.WriteString(fmt.Sprintf("%s %s '%s'\n", symbols[e.Action], e.Kind, e.Key))
+		for _, c := range e.Changes {
+			switch c.op {
+			case "add":
+				b.WriteString(fmt.Sprintf("    %s: added %s\n", c.Path, formatValue(c.To)))
+			case "remove":
+				b.WriteString(fmt.Sprintf("    %s: removed %s\n", c.Path, formatValue(c.From)))
+			default:
+				b.WriteString(fmt.Sprintf("    %s: %s -> %s\n", c.Path, formatValue(c.From), formatValue(c.To)))
+			}
+		}
+	}
+	s := d.Summary()
+	b.WriteString(fmt.Sprintf("diff: %d added, %d changed, %d removed\n", s[ActionCreate], s[ActionUpdate], s[ActionDelete]))
+	return b.String()
+}
+
+// compares two JSON values, recursing into objects so that changes are reported at the deepest level
+func diffValues(path string, from, to interface{}, changes []FieldChange) []FieldChange {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if !fromIsMap || !toIsMap || fromMap == nil || toMap == nil {
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, FieldChange{Path: path, From: from, To: to, op: "replace"})
+		}
+		return changes
+	}
+	keys := make([]string, 0, len(fromMap)+len(toMap))
+	for key := range fromMap {
+		keys = append(keys, key)
+	}
+	for key := range toMap {
+		if _, exists := fromMap[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p := path + "/" + escapePointer(key)
+		f, inFrom := fromMap[key]
+		t, inTo := toMap[key]
+		switch {
+		case !inFrom:
+			changes = append(changes, FieldChange{Path: p, To: t, op: "add"})
+		case !inTo:
+			changes = append(changes, FieldChange{Path: p, From: f, op: "remove"})
+		default:
+			changes = diffValues(p, f, t, changes)
+		}
+	}
+	return changes
+}
+
+// escapes a JSON pointer reference token as per RFC 6901
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// formats a JSON value for the human readable report
+func formatValue(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// the key used to match entities, type attribute keys are only unique within their type
+func diffKey(entity interface{}) string {
+	switch e := entity.(type) {
+	case *ItemTypeAttribute:
+		return attrKey(e.ItemTypeKey, e.Key)
+	case *LinkTypeAttribute:
+		return attrKey(e.LinkTypeKey, e.Key)
+	}
+	return entityKey(entity)
+}
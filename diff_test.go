@@ -0,0 +1,141 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checks that entities are matched by key and nested changes are reported
+func TestDiffData(t *testing.T) {
+	from := &GraphData{
+		Items: []Item{
+			{Key: "item_1", Name: "Item 1", Attribute: map[string]interface{}{"cpu": 2, "ram": 4}, Version: 1},
+			{Key: "item_2", Name: "Item 2", Tag: []interface{}{"a"}},
+			{Key: "item_3", Name: "Item 3"},
+		},
+	}
+	to := &GraphData{
+		Items: []Item{
+			{Key: "item_2", Name: "Item 2", Tag: []interface{}{"a", "b"}},
+			{Key: "item_1", Name: "Item 1", Attribute: map[string]interface{}{"cpu": 4, "disk/size": 10}, Version: 7},
+			{Key: "item_4", Name: "Item 4"},
+		},
+		Links: []Link{{Key: "link_1", StartItemKey: "item_1", EndItemKey: "item_4"}},
+	}
+	diff, err := DiffData(from, to, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := diff.Summary()
+	if s[ActionCreate] != 2 || s[ActionUpdate] != 2 || s[ActionDelete] != 1 {
+		t.Fatalf("unexpected summary: %v\n%s", s, diff)
+	}
+	item1 := diff.Entities[0]
+	var paths []string
+	for _, c := range item1.Changes {
+		paths = append(paths, c.Path)
+	}
+	if item1.Key != "item_1" || !reflect.DeepEqual(paths, []string{"/attribute/cpu", "/attribute/disk~1size", "/attribute/ram"}) {
+		t.Fatalf("unexpected changes: %+v", item1)
+	}
+	// applying the patch to the source data must produce the target data, ignoring order and server fields
+	doc, err := toFieldMap(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patched interface{} = doc
+	for _, op := range diff.Patch() {
+		if patched, err = applyPatchOp(patched, op); err != nil {
+			t.Fatalf("cannot apply %+v: %s", op, err)
+		}
+	}
+	b, _ := json.Marshal(patched)
+	result := new(GraphData)
+	if err = json.Unmarshal(b, result); err != nil {
+		t.Fatal(err)
+	}
+	if diff, _ = DiffData(result, to, nil); !diff.IsEmpty() {
+		t.Fatalf("patched data differs from the target data:\n%s", diff)
+	}
+}
+
+// applies a JSON Patch operation, supports the add, remove and replace operations only
+func applyPatchOp(doc interface{}, op PatchOperation) (interface{}, error) {
+	tokens := strings.Split(op.Path, "/")[1:]
+	for i := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(tokens[i], "~1", "/", -1), "~0", "~", -1)
+	}
+	// navigates to the parent of the target location
+	parent := doc
+	for _, token := range tokens[:len(tokens)-1] {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			parent = p[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, err
+			}
+			parent = p[i]
+		}
+	}
+	last := tokens[len(tokens)-1]
+	value, _ := toJsonValue(op.Value)
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if op.Op == "remove" {
+			delete(p, last)
+		} else {
+			p[last] = value
+		}
+		return doc, nil
+	case []interface{}:
+		if last == "-" {
+			return setParent(doc, tokens[:len(tokens)-1], append(p, value))
+		}
+		i, err := strconv.Atoi(last)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "remove" {
+			return setParent(doc, tokens[:len(tokens)-1], append(p[:i:i], p[i+1:]...))
+		}
+		p[i] = value
+		return doc, nil
+	}
+	return doc, nil
+}
+
+// replaces the list at the specified location, as appending or removing may reallocate it
+func setParent(doc interface{}, tokens []string, list []interface{}) (interface{}, error) {
+	_, err := applyPatchOp(doc, PatchOperation{Op: "replace", Path: "/" + strings.Join(tokens, "/"), Value: list})
+	return doc, err
+}
+
+func toJsonValue(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.Unmarshal(b, &v)
+	return v, err
+}
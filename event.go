@@ -16,16 +16,65 @@
 package oxc
 
 import (
-	"crypto/tls"
 	"fmt"
 	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// the state of the connection to the broker
+type ConnectionStatus int32
+
+const (
+	Disconnected ConnectionStatus = iota
+	Connecting
+	Connected
+	Reconnecting
+)
+
+func (s ConnectionStatus) String() string {
+	switch s {
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	}
+	return "disconnected"
+}
+
+// the error reported when a topic cannot be subscribed to after connecting
+type SubscriptionError struct {
+	Topic string
+	Err   error
+}
+
+func (e *SubscriptionError) Error() string {
+	return fmt.Sprintf("cannot subscribe to topic '%s': %s", e.Topic, e.Err)
+}
+
+func (e *SubscriptionError) Unwrap() error {
+	return e.Err
+}
+
 // MQTT client for change notifications
 type EventManager struct {
-	done   chan bool
 	cfg    *EventConfig
 	client MQTT.Client
+	// the subscriptions by topic, restored when the client reconnects
+	subs map[string]Subscription
+	lock sync.Mutex
+	// the connection status
+	status int32
+	// closed when the manager is disconnected, to stop reconnecting
+	stop     chan struct{}
+	stopOnce sync.Once
+	// the open events channels
+	streams    []*eventStream
+	streamLock sync.RWMutex
 }
 
 // creates a new event manager subscribed to a specific topic
@@ -35,38 +84,63 @@ func NewEventManager(cfg *EventConfig) (*EventManager, error) {
 	if ok, err := cfg.isValid(); !ok {
 		return nil, err
 	}
-	m := new(EventManager)
+	m := &EventManager{cfg: cfg, subs: make(map[string]Subscription), stop: make(chan struct{})}
+	for _, sub := range cfg.subscriptions() {
+		m.subs[sub.topic()] = sub
+	}
+	server, err := cfg.brokerURL()
+	if err != nil {
+		return nil, err
+	}
 	// create connection configuration
-	connOpts := MQTT.NewClientOptions().AddBroker(cfg.Server).SetClientID(cfg.clientId()).SetCleanSession(true)
+	connOpts := MQTT.NewClientOptions().AddBroker(server).SetClientID(cfg.clientId()).SetCleanSession(!cfg.PersistentSession)
 	// add credentials if provided
 	if cfg.hasCredentials() {
 		connOpts.SetUsername(cfg.Username)
 		connOpts.SetPassword(cfg.Password)
 	}
 	// setup tls configuration
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: cfg.InsecureSkipVerify,
-		ClientAuth:         cfg.ClientAuthType,
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
 	}
 	connOpts.SetTLSConfig(tlsConfig)
-	// subscribe to the topic on connection
+	// the manager reconnects itself, so that it controls the backoff and reports the connection status
+	connOpts.SetAutoReconnect(false)
+	// subscribe to the topics on connection, including when reconnecting
 	connOpts.OnConnect = func(c MQTT.Client) {
-		if token := c.Subscribe(cfg.topic(), byte(cfg.Qos), cfg.OnMsgReceived); token.Wait() && token.Error() != nil {
-			panic(token.Error())
+		m.setStatus(Connected)
+		for _, sub := range m.Subscriptions() {
+			if err := m.subscribe(c, sub); err != nil {
+				m.reportError(err)
+			}
+		}
+	}
+	connOpts.OnConnectionLost = func(c MQTT.Client, err error) {
+		policy := cfg.reconnectPolicy()
+		if policy.Disabled {
+			m.setStatus(Disconnected)
+		} else {
+			m.setStatus(Reconnecting)
+		}
+		if cfg.OnConnectionLost != nil {
+			cfg.OnConnectionLost(err)
+		}
+		if !policy.Disabled {
+			go m.reconnect(policy)
 		}
 	}
 	// finally create the client
-	client := MQTT.NewClient(connOpts)
-	// set up the manager
-	m.client = client
-	m.cfg = cfg
+	m.client = MQTT.NewClient(connOpts)
 	// return a new setup manager
 	return m, nil
 }
 
 // connect to the message broker
 func (m *EventManager) Connect() error {
+	m.setStatus(Connecting)
 	if token := m.client.Connect(); token.Wait() && token.Error() != nil {
+		m.setStatus(Disconnected)
 		return token.Error()
 	}
 	fmt.Printf("Connected to %s\n", m.cfg.Server)
@@ -75,5 +149,126 @@ func (m *EventManager) Connect() error {
 
 // disconnect from the message broker
 func (m *EventManager) Disconnect(timeoutMilSecs uint) {
+	m.stopOnce.Do(func() { close(m.stop) })
 	m.client.Disconnect(timeoutMilSecs)
+	m.setStatus(Disconnected)
+}
+
+// the state of the connection to the broker
+func (m *EventManager) Status() ConnectionStatus {
+	return ConnectionStatus(atomic.LoadInt32(&m.status))
+}
+
+func (m *EventManager) setStatus(status ConnectionStatus) {
+	atomic.StoreInt32(&m.status, int32(status))
+}
+
+// reconnects to the broker following the reconnect policy, until connected, disconnected or out of attempts
+func (m *EventManager) reconnect(policy ReconnectPolicy) {
+	for attempt := 1; policy.MaxAttempts == 0 || attempt <= policy.MaxAttempts; attempt++ {
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(policy.delay(attempt)):
+		}
+		token := m.client.Connect()
+		if token.Wait() && token.Error() == nil {
+			if m.cfg.OnConnectionRestored != nil {
+				m.cfg.OnConnectionRestored()
+			}
+			return
+		}
+		m.setStatus(Reconnecting)
+	}
+	m.setStatus(Disconnected)
+	m.reportError(fmt.Errorf("cannot reconnect to %s after %d attempts", m.cfg.Server, policy.MaxAttempts))
+}
+
+// reports an error that cannot be returned
+func (m *EventManager) reportError(err error) {
+	if m.cfg.OnError != nil {
+		m.cfg.OnError(err)
+	}
+}
+
+// adds a subscription, if the manager is connected the topic is subscribed straight away
+// the subscription is restored whenever the manager reconnects
+func (m *EventManager) Subscribe(sub Subscription) error {
+	sub = m.cfg.withDefaults(sub)
+	if err := sub.isValid(); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	if _, exists := m.subs[sub.topic()]; exists {
+		m.lock.Unlock()
+		return fmt.Errorf("already subscribed to topic '%s'", sub.topic())
+	}
+	m.subs[sub.topic()] = sub
+	m.lock.Unlock()
+	if !m.client.IsConnectionOpen() {
+		return nil
+	}
+	if err := m.subscribe(m.client, sub); err != nil {
+		m.lock.Lock()
+		delete(m.subs, sub.topic())
+		m.lock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// removes the subscription to a topic (e.g. IT_HOST, II_host_1 or a topic filter)
+func (m *EventManager) Unsubscribe(topic string) error {
+	m.lock.Lock()
+	_, exists := m.subs[topic]
+	delete(m.subs, topic)
+	m.lock.Unlock()
+	if !exists {
+		return fmt.Errorf("not subscribed to topic '%s'", topic)
+	}
+	if !m.client.IsConnectionOpen() {
+		return nil
+	}
+	if token := m.client.Unsubscribe(topic); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+	return nil
+}
+
+// the current subscriptions sorted by topic
+func (m *EventManager) Subscriptions() []Subscription {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	subs := make([]Subscription, 0, len(m.subs))
+	for _, sub := range m.subs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].topic() < subs[j].topic() })
+	return subs
+}
+
+// subscribes to the topic of a subscription
+func (m *EventManager) subscribe(c MQTT.Client, sub Subscription) error {
+	if token := c.Subscribe(sub.topic(), byte(sub.Qos), m.handler(sub)); token.Wait() && token.Error() != nil {
+		return &SubscriptionError{Topic: sub.topic(), Err: token.Error()}
+	}
+	return nil
+}
+
+// creates the mqtt handler for a subscription, messages are only decoded if there is a typed handler or events channel
+func (m *EventManager) handler(sub Subscription) MQTT.MessageHandler {
+	typed := eventHandler(nil, func(event *ChangeEvent) {
+		if sub.OnChange != nil {
+			sub.OnChange(event)
+		}
+		m.publish(event)
+	}, m.reportError)
+	return func(c MQTT.Client, msg MQTT.Message) {
+		if sub.OnMsgReceived != nil {
+			sub.OnMsgReceived(c, msg)
+		}
+		if sub.OnChange != nil || m.streaming() {
+			typed(c, msg)
+		}
+	}
 }
@@ -17,16 +17,22 @@ package oxc
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 	"github.com/google/uuid"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
 // configuration for the event manager (mqtt broker)
 type EventConfig struct {
-	// the MQTT Server url
+	// the MQTT Server url, e.g. tcp://host:1883, ssl://host:8883, ws://host:8080/mqtt or wss://host:8081/mqtt
+	// mqtt:// and mqtts:// are accepted as aliases of tcp:// and ssl://
 	Server string
 	// the item type for which to get notification changes (itemInstance must be empty)
 	ItemType string
@@ -40,30 +46,203 @@ type EventConfig struct {
 	Password string
 	// skip tls certificate verification
 	InsecureSkipVerify bool
-	// the policy the Server will follow for TLS Client Authentication
+	// Deprecated: this is a server side setting and has no effect on the client, use ClientCertFile and ClientKeyFile
 	ClientAuthType tls.ClientAuthType
+	// the path of a PEM file with the certificate authorities used to verify the broker certificate
+	// if not set, the system certificate pool is used
+	CACertFile string
+	// the PEM encoded certificate authorities used to verify the broker certificate, in addition to CACertFile
+	CACert []byte
+	// the path of the PEM encoded client certificate presented to brokers requiring client authentication
+	ClientCertFile string
+	// the path of the PEM encoded private key of the client certificate
+	ClientKeyFile string
+	// the name used to verify the broker certificate, defaults to the host in the Server url
+	ServerName string
 	// a function to process received messages
 	OnMsgReceived MQTT.MessageHandler
+	// a function to process received messages decoded as change events
+	OnChange ChangeHandler
+	// a function called with the errors that cannot be returned, e.g. when a message cannot be decoded
+	OnError func(err error)
+	// additional subscriptions, subscriptions can also be added and removed once the manager is created
+	Subscriptions []Subscription
+	// the client identifier, required for persistent sessions, defaults to a unique identifier
+	ClientId string
+	// if true, the broker keeps the subscriptions and queues the messages (qos 1 and 2) while the client is disconnected
+	PersistentSession bool
+	// how to reconnect when the connection is lost, defaults to DefaultReconnectPolicy
+	Reconnect *ReconnectPolicy
+	// a function called when the connection to the broker is lost
+	OnConnectionLost func(err error)
+	// a function called when the connection to the broker is restored
+	OnConnectionRestored func()
+	// the number of events buffered by each channel returned by EventManager.Events, defaults to 100
+	EventBuffer int
+	// what to do when an events channel buffer is full, defaults to Block
+	Backpressure BackpressurePolicy
+	// a function called with the events dropped by the backpressure policy
+	OnDropped ChangeHandler
 }
 
-func (c *EventConfig) hasCredentials() bool {
-	return len(c.Username) > 0 && len(c.Password) > 0
+// what to do with a new event when an events channel buffer is full
+type BackpressurePolicy int
+
+const (
+	// waits until there is space in the buffer, which delays the delivery of all messages
+	Block BackpressurePolicy = iota
+	// drops the oldest buffered event to make space for the new one
+	DropOldest
+	// drops the new event
+	DropNewest
+)
+
+// the default number of events buffered by an events channel
+const defaultEventBuffer = 100
+
+// the policy to reconnect to the broker when the connection is lost
+// the delay between attempts starts at InitialInterval and is multiplied by Multiplier after each attempt up to MaxInterval
+type ReconnectPolicy struct {
+	// if true, the manager does not reconnect
+	Disabled bool
+	// the delay before the first attempt
+	InitialInterval time.Duration
+	// the maximum delay between attempts
+	MaxInterval time.Duration
+	// the factor the delay is multiplied by after each attempt
+	Multiplier float64
+	// the maximum number of attempts, zero means no limit
+	MaxAttempts int
+}
+
+// the reconnect policy used if none is configured
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialInterval: time.Second,
+	MaxInterval:     2 * time.Minute,
+	Multiplier:      2,
+}
+
+// the delay before the specified reconnection attempt, starting at 1
+func (p *ReconnectPolicy) delay(attempt int) time.Duration {
+	delay := float64(p.InitialInterval)
+	for i := 1; i < attempt; i++ {
+		delay *= p.Multiplier
+		if delay >= float64(p.MaxInterval) {
+			return p.MaxInterval
+		}
+	}
+	return time.Duration(delay)
+}
+
+// the reconnect policy with defaults for the values not set
+func (c *EventConfig) reconnectPolicy() ReconnectPolicy {
+	if c.Reconnect == nil {
+		return DefaultReconnectPolicy
+	}
+	p := *c.Reconnect
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = DefaultReconnectPolicy.InitialInterval
+	}
+	if p.MaxInterval < p.InitialInterval {
+		p.MaxInterval = DefaultReconnectPolicy.MaxInterval
+		if p.MaxInterval < p.InitialInterval {
+			p.MaxInterval = p.InitialInterval
+		}
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultReconnectPolicy.Multiplier
+	}
+	return p
+}
+
+// a subscription to change notifications
+type Subscription struct {
+	// the item type for which to get notification changes (ItemInstance and Topic must be empty)
+	ItemType string
+	// the item instance for which to get notification changes (ItemType and Topic must be empty)
+	ItemInstance string
+	// an mqtt topic filter, which can use the + and # wildcards, e.g. # for all notifications
+	// (ItemType and ItemInstance must be empty)
+	Topic string
+	// the quality of service for message delivery - 0: at most once, 1: at least once, 2: exactly once
+	Qos int
+	// a function to process received messages, defaults to the EventConfig handler
+	OnMsgReceived MQTT.MessageHandler
+	// a function to process received messages decoded as change events, defaults to the EventConfig handler
+	OnChange ChangeHandler
+}
+
+// the mqtt topic filter of the subscription
+func (s *Subscription) topic() string {
+	switch {
+	case len(s.Topic) > 0:
+		return s.Topic
+	case len(s.ItemInstance) > 0:
+		return fmt.Sprintf("II_%s", s.ItemInstance)
+	}
+	return fmt.Sprintf("IT_%s", s.ItemType)
+}
+
+// check the subscription is valid
+func (s *Subscription) isValid() error {
+	set := 0
+	for _, value := range []string{s.ItemType, s.ItemInstance, s.Topic} {
+		if len(value) > 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("a subscription requires one of itemType, itemInstance or topic")
+	}
+	if s.Qos < 0 || s.Qos > 2 {
+		return fmt.Errorf("invalid qos %d for topic '%s', use 0, 1 or 2", s.Qos, s.topic())
+	}
+	if s.OnMsgReceived == nil && s.OnChange == nil {
+		return fmt.Errorf("a handler for messages received on topic '%s' must be provided", s.topic())
+	}
+	return nil
+}
+
+// the subscriptions in the configuration, using the configuration handlers where not set
+func (c *EventConfig) subscriptions() []Subscription {
+	var subs []Subscription
+	if len(c.ItemInstance) > 0 || len(c.ItemType) > 0 {
+		subs = append(subs, Subscription{ItemType: c.ItemType, ItemInstance: c.ItemInstance, Qos: c.Qos})
+	}
+	subs = append(subs, c.Subscriptions...)
+	for i := range subs {
+		subs[i] = c.withDefaults(subs[i])
+	}
+	return subs
 }
 
-func (c *EventConfig) topic() string {
-	if len(c.ItemInstance) > 0 {
-		return fmt.Sprintf("II_%s", c.ItemInstance)
+// sets the handlers of a subscription to the configuration handlers if it has none
+func (c *EventConfig) withDefaults(sub Subscription) Subscription {
+	if sub.OnMsgReceived == nil && sub.OnChange == nil {
+		sub.OnMsgReceived = c.OnMsgReceived
+		sub.OnChange = c.OnChange
 	}
-	return fmt.Sprintf("IT_%s", c.ItemType)
+	return sub
+}
+
+func (c *EventConfig) hasCredentials() bool {
+	return len(c.Username) > 0 && len(c.Password) > 0
 }
 
 // unique identifier for the client
 func (c *EventConfig) clientId() string {
+	if len(c.ClientId) > 0 {
+		return c.ClientId
+	}
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknown-host"
 	}
-	return fmt.Sprintf("%s-%s-%s", c.topic(), hostname, uuid.New())
+	prefix := "oxc"
+	if len(c.ItemInstance) > 0 || len(c.ItemType) > 0 {
+		prefix = (&Subscription{ItemType: c.ItemType, ItemInstance: c.ItemInstance}).topic()
+	}
+	return fmt.Sprintf("%s-%s-%s", prefix, hostname, uuid.New())
 }
 
 // check the configuration is valid
@@ -74,14 +253,87 @@ func (c *EventConfig) isValid() (bool, error) {
 	if len(c.ItemInstance) > 0 && len(c.ItemType) > 0 {
 		return false, errors.New("itemType and itemInstance both have values, only one is allowed")
 	}
-	if len(c.ItemInstance) == 0 && len(c.ItemType) == 0 {
-		return false, errors.New("itemType and itemInstance do not have values, one is required")
+	if len(c.ItemInstance) == 0 && len(c.ItemType) == 0 && len(c.Subscriptions) == 0 {
+		return false, errors.New("itemType and itemInstance do not have values and there are no subscriptions, one is required")
+	}
+	if _, err := c.brokerURL(); err != nil {
+		return false, err
+	}
+	if (len(c.ClientCertFile) > 0) != (len(c.ClientKeyFile) > 0) {
+		return false, errors.New("a client certificate requires both clientCertFile and clientKeyFile")
 	}
 	if len(c.Username) > 0 && len(c.Password) == 0 {
 		return false, errors.New("username with no password, provide password")
 	}
-	if c.OnMsgReceived == nil {
+	if c.PersistentSession && len(c.ClientId) == 0 {
+		return false, errors.New("a persistent session requires a client id, so that the broker recognises the client when it reconnects")
+	}
+	if (len(c.ItemInstance) > 0 || len(c.ItemType) > 0) && c.OnMsgReceived == nil && c.OnChange == nil {
 		return false, errors.New("a handler for received messages must be provided")
 	}
+	topics := make(map[string]bool)
+	for _, sub := range c.subscriptions() {
+		if err := sub.isValid(); err != nil {
+			return false, err
+		}
+		if topics[sub.topic()] {
+			return false, fmt.Errorf("more than one subscription to topic '%s'", sub.topic())
+		}
+		topics[sub.topic()] = true
+	}
 	return true, nil
 }
+
+// the broker url with any scheme alias replaced by the scheme understood by the mqtt client
+func (c *EventConfig) brokerURL() (string, error) {
+	u, err := url.Parse(c.Server)
+	if err != nil {
+		return "", fmt.Errorf("invalid server url '%s': %s", c.Server, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "tcp", "ssl", "tls", "tcps", "ws", "wss":
+		u.Scheme = strings.ToLower(u.Scheme)
+	case "mqtt":
+		u.Scheme = "tcp"
+	case "mqtts":
+		u.Scheme = "ssl"
+	default:
+		return "", fmt.Errorf("invalid server url '%s': use a tcp, ssl, tls, tcps, ws, wss, mqtt or mqtts scheme", c.Server)
+	}
+	if len(u.Host) == 0 {
+		return "", fmt.Errorf("invalid server url '%s': the host is missing", c.Server)
+	}
+	return u.String(), nil
+}
+
+// the tls configuration used to connect to ssl, tls, tcps and wss urls
+func (c *EventConfig) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		ServerName:         c.ServerName,
+	}
+	if len(c.CACertFile) > 0 || len(c.CACert) > 0 {
+		pool := x509.NewCertPool()
+		if len(c.CACertFile) > 0 {
+			pem, err := ioutil.ReadFile(c.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read CA certificate file: %s", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA certificate file '%s'", c.CACertFile)
+			}
+		}
+		if len(c.CACert) > 0 && !pool.AppendCertsFromPEM(c.CACert) {
+			return nil, errors.New("no certificates found in the CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+	if len(c.ClientCertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %s", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,146 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// checks the broker url schemes
+func TestEventConfig_BrokerURL(t *testing.T) {
+	for server, expected := range map[string]string{
+		"tcp://localhost:1883":      "tcp://localhost:1883",
+		"mqtts://localhost:8883":    "ssl://localhost:8883",
+		"WSS://localhost:8081/mqtt": "wss://localhost:8081/mqtt",
+		"http://localhost:1883":     "",
+		"localhost:1883":            "",
+	} {
+		url, err := (&EventConfig{Server: server}).brokerURL()
+		if url != expected || (err == nil) != (len(expected) > 0) {
+			t.Fatalf("server '%s': unexpected url '%s' (%v)", server, url, err)
+		}
+	}
+}
+
+// checks that the broker certificate is verified with the configured CA and the client certificate is presented
+func TestEventConfig_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oxc-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caCert, caKey := newTestCert(t, nil, nil, "test-ca")
+	serverCert, serverKey := newTestCert(t, caCert, caKey, "broker.test")
+	clientCert, clientKey := newTestCert(t, caCert, caKey, "client")
+	caFile := writeTestPEM(t, dir, "ca.pem", "CERTIFICATE", caCert.Raw)
+	certFile := writeTestPEM(t, dir, "client.pem", "CERTIFICATE", clientCert.Raw)
+	keyBytes, _ := x509.MarshalECPrivateKey(clientKey)
+	keyFile := writeTestPEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyBytes)
+	// a tls server requiring client certificates signed by the CA
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	cfg := &EventConfig{CACertFile: caFile, ClientCertFile: certFile, ClientKeyFile: keyFile, ServerName: "broker.test"}
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := tls.Dial("tcp", listener.Addr().String(), tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	// the broker certificate is rejected for a different server name
+	cfg.ServerName = "other.test"
+	if tlsConfig, err = cfg.tlsConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if conn, err = tls.Dial("tcp", listener.Addr().String(), tlsConfig); err == nil {
+		conn.Close()
+		t.Fatal("expected the broker certificate to be rejected")
+	}
+}
+
+// creates a certificate signed by the parent, or self-signed if the parent is nil
+func newTestCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writeTestPEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,130 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+)
+
+// a change notification published by the Web API when an item changes
+type ChangeEvent struct {
+	// the key of the item that changed
+	ItemKey string `json:"itemKey"`
+	// the type of the item that changed
+	ItemType string `json:"itemType"`
+	// the operation performed on the item (see Op* constants)
+	Operation string `json:"operation"`
+	// the item version after the change
+	Version int64 `json:"version"`
+	// who made the change
+	ChangedBy string `json:"changedBy"`
+	// when the change was made
+	Time Timestamp `json:"time"`
+	// the item after the change, if included in the notification
+	Item *Item `json:"item,omitempty"`
+	// the topic the notification was received on
+	Topic string `json:"-"`
+}
+
+// handles a typed change notification
+type ChangeHandler func(event *ChangeEvent)
+
+// the error reported when a notification cannot be decoded
+type EventDecodeError struct {
+	// the topic the notification was received on
+	Topic string
+	// the notification payload
+	Payload []byte
+	// the reason the payload cannot be decoded
+	Err error
+}
+
+func (e *EventDecodeError) Error() string {
+	return fmt.Sprintf("cannot decode notification received on topic '%s': %s", e.Topic, e.Err)
+}
+
+func (e *EventDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodes a change notification
+// the payload is either a change event or, for the Web API versions that publish the item itself, an item
+func decodeChangeEvent(topic string, payload []byte) (*ChangeEvent, error) {
+	fail := func(err error) (*ChangeEvent, error) {
+		return nil, &EventDecodeError{Topic: topic, Payload: payload, Err: err}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return fail(err)
+	}
+	event := &ChangeEvent{Topic: topic}
+	if _, ok := fields["itemKey"]; ok {
+		if err := json.Unmarshal(payload, event); err != nil {
+			return fail(err)
+		}
+		if event.Item != nil && len(event.ItemType) == 0 {
+			event.ItemType = event.Item.Type
+		}
+		return event, nil
+	}
+	if _, ok := fields["key"]; !ok {
+		return fail(fmt.Errorf("the payload is neither a change event nor an item"))
+	}
+	item := new(Item)
+	if err := json.Unmarshal(payload, item); err != nil {
+		return fail(err)
+	}
+	event.ItemKey = item.Key
+	event.ItemType = item.Type
+	event.Version = item.Version
+	event.ChangedBy = item.ChangedBy
+	event.Item = item
+	changed, err := changedTime(item.Created, item.Updated)
+	if err != nil {
+		return fail(err)
+	}
+	event.Time = Timestamp{Time: changed}
+	if operation, ok := fields["operation"]; ok {
+		_ = json.Unmarshal(operation, &event.Operation)
+	}
+	return event, nil
+}
+
+// creates the mqtt handler for a subscription
+// raw: called with every message received, can be nil
+// typed: called with the decoded change event, can be nil
+// onError: called when a message cannot be decoded, can be nil
+func eventHandler(raw MQTT.MessageHandler, typed ChangeHandler, onError func(error)) MQTT.MessageHandler {
+	return func(client MQTT.Client, msg MQTT.Message) {
+		if raw != nil {
+			raw(client, msg)
+		}
+		if typed == nil {
+			return
+		}
+		event, err := decodeChangeEvent(msg.Topic(), msg.Payload())
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			return
+		}
+		typed(event)
+	}
+}
+
@@ -0,0 +1,60 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"testing"
+)
+
+// a message received from the broker
+type testMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *testMessage) Duplicate() bool   { return false }
+func (m *testMessage) Qos() byte         { return 0 }
+func (m *testMessage) Retained() bool    { return false }
+func (m *testMessage) Topic() string     { return m.topic }
+func (m *testMessage) MessageID() uint16 { return 0 }
+func (m *testMessage) Payload() []byte   { return m.payload }
+func (m *testMessage) Ack()              {}
+
+// checks that notifications are decoded and decode errors are reported
+func TestEventHandler(t *testing.T) {
+	var (
+		events []*ChangeEvent
+		errs   []error
+	)
+	handler := eventHandler(nil, func(event *ChangeEvent) { events = append(events, event) }, func(err error) { errs = append(errs, err) })
+	handler(nil, &testMessage{topic: "IT_HOST", payload: []byte(`{"itemKey": "host_1", "itemType": "HOST", "operation": "U", "version": 3, "changedBy": "admin", "time": "01-01-2050 10:30:00+0100"}`)})
+	handler(nil, &testMessage{topic: "II_host_2", payload: []byte(`{"key": "host_2", "type": "HOST", "version": 1, "created": "01-01-2050 10:30:00+0100"}`)})
+	handler(nil, &testMessage{topic: "IT_HOST", payload: []byte(`not json`)})
+	if len(events) != 2 || len(errs) != 1 {
+		t.Fatalf("expected 2 events and 1 error, got %d and %d", len(events), len(errs))
+	}
+	if e := events[0]; e.ItemKey != "host_1" || e.Operation != OpUpdate || e.Version != 3 || e.Time.Year() != 2050 || e.Topic != "IT_HOST" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	if e := events[1]; e.ItemKey != "host_2" || e.ItemType != "HOST" || e.Item == nil || e.Time.IsZero() {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	var decodeErr *EventDecodeError
+	if !errors.As(errs[0], &decodeErr) || string(decodeErr.Payload) != "not json" {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+}
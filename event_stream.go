@@ -0,0 +1,168 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+)
+
+// a channel of events returned by EventManager.Events
+type eventStream struct {
+	ch     chan *ChangeEvent
+	policy BackpressurePolicy
+	// closed when the stream ends, to release blocked senders
+	done chan struct{}
+	// serialises the senders, so that dropping the oldest event and sending the new one is atomic
+	lock sync.Mutex
+}
+
+// sends an event following the backpressure policy
+func (s *eventStream) send(event *ChangeEvent, onDropped ChangeHandler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// the channel may be closed once the stream has ended
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	dropped := func(e *ChangeEvent) {
+		if onDropped != nil {
+			onDropped(e)
+		}
+	}
+	switch s.policy {
+	case DropNewest:
+		select {
+		case s.ch <- event:
+		case <-s.done:
+		default:
+			dropped(event)
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- event:
+				return
+			case <-s.done:
+				return
+			default:
+			}
+			select {
+			case oldest := <-s.ch:
+				dropped(oldest)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- event:
+		case <-s.done:
+		}
+	}
+}
+
+// gets a channel receiving the change events of all subscriptions
+// the channel is buffered (see EventConfig.EventBuffer) and, when full, the EventConfig.Backpressure policy applies
+// the channel is closed when the context is cancelled or the manager is disconnected
+func (m *EventManager) Events(ctx context.Context) <-chan *ChangeEvent {
+	size := m.cfg.EventBuffer
+	if size <= 0 {
+		size = defaultEventBuffer
+	}
+	s := &eventStream{ch: make(chan *ChangeEvent, size), policy: m.cfg.Backpressure, done: make(chan struct{})}
+	m.streamLock.Lock()
+	m.streams = append(m.streams, s)
+	m.streamLock.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-m.stop:
+		}
+		// releases any sender blocked on the stream before taking the lock
+		close(s.done)
+		m.streamLock.Lock()
+		for i, stream := range m.streams {
+			if stream == s {
+				m.streams = append(m.streams[:i], m.streams[i+1:]...)
+				break
+			}
+		}
+		m.streamLock.Unlock()
+		// no sender can hold the stream now
+		s.lock.Lock()
+		close(s.ch)
+		s.lock.Unlock()
+	}()
+	return s.ch
+}
+
+// processes the change events of all subscriptions using a pool of workers
+// events for the same item are always processed by the same worker, so that they are processed in order
+// when the context is cancelled, no more events are received and the events already received are processed
+// before returning
+func (m *EventManager) Consume(ctx context.Context, workers int, handler ChangeHandler) {
+	if workers <= 0 {
+		workers = 1
+	}
+	events := m.Events(ctx)
+	var wg sync.WaitGroup
+	queues := make([]chan *ChangeEvent, workers)
+	for i := range queues {
+		queues[i] = make(chan *ChangeEvent, cap(events))
+		wg.Add(1)
+		go func(queue chan *ChangeEvent) {
+			defer wg.Done()
+			for event := range queue {
+				handler(event)
+			}
+		}(queues[i])
+	}
+	// the events channel is closed when the context is cancelled, after the buffered events are received
+	for event := range events {
+		queues[workerOf(event.ItemKey, workers)] <- event
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+}
+
+// the worker that processes the events of an item
+func workerOf(key string, workers int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
+
+// true if any events channel is open
+func (m *EventManager) streaming() bool {
+	m.streamLock.RLock()
+	defer m.streamLock.RUnlock()
+	return len(m.streams) > 0
+}
+
+// sends an event to the open events channels
+func (m *EventManager) publish(event *ChangeEvent) {
+	m.streamLock.RLock()
+	streams := append([]*eventStream{}, m.streams...)
+	m.streamLock.RUnlock()
+	for _, s := range streams {
+		s.send(event, m.cfg.OnDropped)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"context"
+	"fmt"
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"sync"
+	"testing"
+	"time"
+)
+
+// creates an event manager that is not connected and a function to simulate received notifications
+func newTestEventManager(t *testing.T, cfg *EventConfig) (*EventManager, func(key string, version int)) {
+	cfg.Server = "tcp://127.0.0.1:1883"
+	cfg.ItemType = "HOST"
+	cfg.OnMsgReceived = func(MQTT.Client, MQTT.Message) {}
+	m, err := NewEventManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := m.handler(m.Subscriptions()[0])
+	return m, func(key string, version int) {
+		handler(nil, &testMessage{topic: "IT_HOST", payload: []byte(fmt.Sprintf(`{"itemKey": "%s", "version": %d}`, key, version))})
+	}
+}
+
+// checks the backpressure policies
+func TestEventManager_Events(t *testing.T) {
+	for _, test := range []struct {
+		policy   BackpressurePolicy
+		received string
+	}{
+		{DropNewest, "[1 2]"},
+		{DropOldest, "[2 3]"},
+	} {
+		var dropped []int64
+		m, receive := newTestEventManager(t, &EventConfig{
+			EventBuffer:  2,
+			Backpressure: test.policy,
+			OnDropped:    func(event *ChangeEvent) { dropped = append(dropped, event.Version) },
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		events := m.Events(ctx)
+		receive("host_1", 1)
+		receive("host_1", 2)
+		receive("host_1", 3)
+		cancel()
+		var versions []int64
+		for event := range events {
+			versions = append(versions, event.Version)
+		}
+		if fmt.Sprint(versions) != test.received || len(dropped) != 1 {
+			t.Fatalf("policy %d: unexpected events %v, dropped %v", test.policy, versions, dropped)
+		}
+	}
+}
+
+// checks that events are processed concurrently in order for each item and drained on cancellation
+func TestEventManager_Consume(t *testing.T) {
+	m, receive := newTestEventManager(t, &EventConfig{EventBuffer: 1000})
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		mu        sync.Mutex
+		processed = make(map[string][]int64)
+		done      = make(chan struct{})
+	)
+	go func() {
+		m.Consume(ctx, 4, func(event *ChangeEvent) {
+			mu.Lock()
+			processed[event.ItemKey] = append(processed[event.ItemKey], event.Version)
+			mu.Unlock()
+		})
+		close(done)
+	}()
+	// waits for the events channel to be open before sending
+	for !m.streaming() {
+		time.Sleep(time.Millisecond)
+	}
+	for version := 1; version <= 50; version++ {
+		for item := 0; item < 5; item++ {
+			receive(fmt.Sprintf("host_%d", item), version)
+		}
+	}
+	cancel()
+	<-done
+	for key, versions := range processed {
+		if len(versions) != 50 {
+			t.Fatalf("item %s: expected 50 events, got %d", key, len(versions))
+		}
+		for i, version := range versions {
+			if version != int64(i+1) {
+				t.Fatalf("item %s: events out of order: %v", key, versions)
+			}
+		}
+	}
+	if len(processed) != 5 {
+		t.Fatalf("expected events for 5 items, got %d", len(processed))
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os/signal"
 	"syscall"
 	"testing"
+	"time"
 )
 
 // how to use the event manager
@@ -49,3 +50,67 @@ func TestReceiver(t *testing.T) {
 func onMsgReceived(client mqtt.Client, msg mqtt.Message) {
 	fmt.Printf("Received message on topic: %s\nMessage: %s\n", msg.Topic(), msg.Payload())
 }
+
+// checks that subscriptions can be added and removed before connecting
+func TestEventManager_Subscribe(t *testing.T) {
+	m, err := NewEventManager(&EventConfig{
+		Server:        "tcp://127.0.0.1:1883",
+		ItemType:      "HOST",
+		OnMsgReceived: onMsgReceived,
+		Subscriptions: []Subscription{
+			{ItemInstance: "TEST_APP_01", Qos: 2},
+			{Topic: "#", OnChange: func(event *ChangeEvent) {}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Subscribe(Subscription{ItemType: "HOST"}); err == nil {
+		t.Fatal("expected an error subscribing twice to the same topic")
+	}
+	if err = m.Subscribe(Subscription{ItemType: "DB", Qos: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Unsubscribe("II_TEST_APP_01"); err != nil {
+		t.Fatal(err)
+	}
+	var topics []string
+	for _, sub := range m.Subscriptions() {
+		topics = append(topics, sub.topic())
+	}
+	if fmt.Sprint(topics) != "[# IT_DB IT_HOST]" {
+		t.Fatalf("unexpected subscriptions: %v", topics)
+	}
+	if _, err = NewEventManager(&EventConfig{Server: "tcp://127.0.0.1:1883", Subscriptions: []Subscription{{ItemType: "HOST"}}}); err == nil {
+		t.Fatal("expected an error for a subscription without a handler")
+	}
+}
+
+// checks the reconnect backoff and the connection status when the broker is not available
+func TestEventManager_Reconnect(t *testing.T) {
+	policy := (&EventConfig{Reconnect: &ReconnectPolicy{InitialInterval: time.Second, MaxInterval: 5 * time.Second}}).reconnectPolicy()
+	var delays []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, policy.delay(attempt))
+	}
+	if fmt.Sprint(delays) != "[1s 2s 4s 5s 5s]" {
+		t.Fatalf("unexpected delays: %v", delays)
+	}
+	m, err := NewEventManager(&EventConfig{
+		Server:        "tcp://127.0.0.1:1",
+		ItemType:      "HOST",
+		OnMsgReceived: onMsgReceived,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Connect(); err == nil {
+		t.Fatal("expected an error connecting to a closed port")
+	}
+	if m.Status() != Disconnected {
+		t.Fatalf("expected disconnected, got %s", m.Status())
+	}
+	if _, err = NewEventManager(&EventConfig{Server: "tcp://127.0.0.1:1", ItemType: "HOST", OnMsgReceived: onMsgReceived, PersistentSession: true}); err == nil {
+		t.Fatal("expected an error for a persistent session without client id")
+	}
+}
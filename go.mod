@@ -6,5 +6,7 @@ require (
 	github.com/eclipse/paho.mqtt.golang v1.2.0
 	github.com/google/uuid v1.1.1
 	github.com/rs/zerolog v1.18.0
+	github.com/xeipuuv/gojsonschema v1.2.0
 	golang.org/x/net v0.0.0-20200822124328-c89045814202 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -33,6 +33,14 @@ type GraphData struct {
 	Links              []Link              `json:"links"`
 }
 
+// options to filter the data exported from a model
+type ExportOptions struct {
+	// if set, only exports items in the specified partition
+	Partition string
+	// if set, only exports items of the specified item types
+	ItemTypes []string
+}
+
 // Get the Item in the http Response
 func (data *GraphData) decode(response *http.Response) (*GraphData, error) {
 	result := new(GraphData)
@@ -59,3 +67,15 @@ func (data *GraphData) bytes() (*[]byte, error) {
 	b, err := ToJson(data)
 	return &b, err
 }
+
+// appends the entities in other to the graph data
+func (data *GraphData) merge(other *GraphData) {
+	data.Models = append(data.Models, other.Models...)
+	data.ItemTypes = append(data.ItemTypes, other.ItemTypes...)
+	data.ItemTypeAttributes = append(data.ItemTypeAttributes, other.ItemTypeAttributes...)
+	data.LinkTypes = append(data.LinkTypes, other.LinkTypes...)
+	data.LinkTypeAttribute = append(data.LinkTypeAttribute, other.LinkTypeAttribute...)
+	data.LinkRules = append(data.LinkRules, other.LinkRules...)
+	data.Items = append(data.Items, other.Items...)
+	data.Links = append(data.Links, other.Links...)
+}
@@ -0,0 +1,172 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// a referential integrity problem found in GraphData
+type IntegrityProblem struct {
+	// the location of the entity in the graph data (e.g. items[3])
+	Location string
+	// the kind of entity (e.g. item, linkRule)
+	Kind string
+	// the entity key
+	Key string
+	// the description of the problem
+	Reason string
+}
+
+func (p IntegrityProblem) String() string {
+	return fmt.Sprintf("%s %s '%s': %s", p.Location, p.Kind, p.Key, p.Reason)
+}
+
+// the error returned when GraphData has referential integrity problems
+type IntegrityError struct {
+	Problems []IntegrityProblem
+}
+
+func (e *IntegrityError) Error() string {
+	msg := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msg[i] = p.String()
+	}
+	return fmt.Sprintf("%d integrity problem(s) found: %s", len(e.Problems), strings.Join(msg, "; "))
+}
+
+// checks offline that the references between the entities in the graph data are valid and keys are unique
+// refs: other graph data (e.g. exported from the Web API) holding entities that can be referenced but are not checked
+func (data *GraphData) CheckIntegrity(refs ...*GraphData) error {
+	var problems []IntegrityProblem
+	add := func(location, kind, key, reason string, args ...interface{}) {
+		problems = append(problems, IntegrityProblem{Location: location, Kind: kind, Key: key, Reason: fmt.Sprintf(reason, args...)})
+	}
+	// the keys of the entities that can be referenced, by kind
+	known := make(map[string]map[string]bool)
+	for _, kind := range kindOrder {
+		known[kind] = make(map[string]bool)
+	}
+	// the item type of each item
+	itemTypes := make(map[string]string)
+	// the allowed link rules as link type/start item type/end item type
+	rules := make(map[string]bool)
+	register := func(d *GraphData) {
+		for _, e := range d.Models {
+			known[KindModel][e.Key] = true
+		}
+		for _, e := range d.ItemTypes {
+			known[KindItemType][e.Key] = true
+		}
+		for _, e := range d.LinkTypes {
+			known[KindLinkType][e.Key] = true
+		}
+		for _, e := range d.LinkRules {
+			rules[ruleId(e.LinkTypeKey, e.StartItemTypeKey, e.EndItemTypeKey)] = true
+		}
+		for _, e := range d.Items {
+			known[KindItem][e.Key] = true
+			itemTypes[e.Key] = e.Type
+		}
+	}
+	for _, ref := range refs {
+		register(ref)
+	}
+	register(data)
+	// checks key uniqueness within each kind
+	entities := data.entities()
+	for _, kind := range kindOrder {
+		seen := make(map[string]string)
+		for i, entity := range entities[kind] {
+			location := fmt.Sprintf("%s[%d]", kindFields[kind], i)
+			key := entityKey(entity)
+			if len(key) == 0 {
+				add(location, kind, key, "key is missing")
+				continue
+			}
+			if first, exists := seen[key]; exists {
+				add(location, kind, key, "duplicate key, already defined at %s", first)
+				continue
+			}
+			seen[key] = location
+		}
+	}
+	ref := func(location, kind, key, field, refKind, refKey string) {
+		if len(refKey) == 0 {
+			add(location, kind, key, "%s is missing", field)
+		} else if !known[refKind][refKey] {
+			add(location, kind, key, "%s '%s' does not exist", field, refKey)
+		}
+	}
+	for i, e := range data.ItemTypes {
+		ref(fmt.Sprintf("itemTypes[%d]", i), KindItemType, e.Key, "model", KindModel, e.Model)
+	}
+	for i, e := range data.ItemTypeAttributes {
+		ref(fmt.Sprintf("itemTypeAttributes[%d]", i), KindItemTypeAttribute, e.Key, "item type", KindItemType, e.ItemTypeKey)
+	}
+	for i, e := range data.LinkTypes {
+		ref(fmt.Sprintf("linkTypes[%d]", i), KindLinkType, e.Key, "model", KindModel, e.Model)
+	}
+	for i, e := range data.LinkTypeAttribute {
+		ref(fmt.Sprintf("linkTypeAttributes[%d]", i), KindLinkTypeAttribute, e.Key, "link type", KindLinkType, e.LinkTypeKey)
+	}
+	for i, e := range data.LinkRules {
+		location := fmt.Sprintf("linkRules[%d]", i)
+		ref(location, KindLinkRule, e.Key, "link type", KindLinkType, e.LinkTypeKey)
+		ref(location, KindLinkRule, e.Key, "start item type", KindItemType, e.StartItemTypeKey)
+		ref(location, KindLinkRule, e.Key, "end item type", KindItemType, e.EndItemTypeKey)
+	}
+	for i, e := range data.Items {
+		ref(fmt.Sprintf("items[%d]", i), KindItem, e.Key, "item type", KindItemType, e.Type)
+	}
+	for i, e := range data.Links {
+		location := fmt.Sprintf("links[%d]", i)
+		before := len(problems)
+		ref(location, KindLink, e.Key, "link type", KindLinkType, e.Type)
+		ref(location, KindLink, e.Key, "start item", KindItem, e.StartItemKey)
+		ref(location, KindLink, e.Key, "end item", KindItem, e.EndItemKey)
+		// only checks the link rules if all references are valid
+		if len(problems) > before {
+			continue
+		}
+		startType, endType := itemTypes[e.StartItemKey], itemTypes[e.EndItemKey]
+		if !rules[ruleId(e.Type, startType, endType)] {
+			add(location, KindLink, e.Key, "no link rule allows a '%s' link from a '%s' item to a '%s' item", e.Type, startType, endType)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return &IntegrityError{Problems: problems}
+}
+
+// the GraphData JSON field holding each kind of entity
+var kindFields = map[string]string{
+	KindModel:             "models",
+	KindItemType:          "itemTypes",
+	KindItemTypeAttribute: "itemTypeAttributes",
+	KindLinkType:          "linkTypes",
+	KindLinkTypeAttribute: "linkTypeAttributes",
+	KindLinkRule:          "linkRules",
+	KindItem:              "items",
+	KindLink:              "links",
+}
+
+// the identifier of a link rule
+func ruleId(linkType, startItemType, endItemType string) string {
+	return fmt.Sprintf("%s|%s|%s", linkType, startItemType, endItemType)
+}
@@ -0,0 +1,45 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import "testing"
+
+// checks the references in graph data offline
+func TestGraphData_CheckIntegrity(t *testing.T) {
+	data := getData()
+	if err := data.CheckIntegrity(); err != nil {
+		t.Fatal(err)
+	}
+	data.Items = []Item{
+		{Key: "state_1", Name: "State 1", Type: "TF_STATE"},
+		{Key: "resource_1", Name: "Resource 1", Type: "TF_RESOURCE"},
+		{Key: "resource_1", Name: "Resource 1", Type: "TF_RESOURCE"},
+	}
+	data.Links = []Link{
+		{Key: "link_1", Type: "TF_STATE_LINK", StartItemKey: "state_1", EndItemKey: "resource_1"},
+		// no rule allows a resource to link to a state
+		{Key: "link_2", Type: "TF_STATE_LINK", StartItemKey: "resource_1", EndItemKey: "state_1"},
+		{Key: "link_3", Type: "TF_STATE_LINK", StartItemKey: "state_1", EndItemKey: "resource_2"},
+	}
+	err := data.CheckIntegrity()
+	if err == nil {
+		t.Fatal("expected integrity problems")
+	}
+	problems := err.(*IntegrityError).Problems
+	if len(problems) != 3 || problems[0].Location != "items[2]" || problems[1].Key != "link_2" || problems[2].Key != "link_3" {
+		t.Fatalf("unexpected problems: %s", err)
+	}
+}
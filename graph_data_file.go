@@ -0,0 +1,419 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// the format of a GraphData file
+type DataFormat string
+
+const (
+	FormatYAML DataFormat = "yaml"
+	FormatJSON DataFormat = "json"
+)
+
+// the key used in a data file to include other files or directories, relative to the including file
+// for example:
+//   $include:
+//     - models.yaml
+//     - items/
+const includeKey = "$include"
+
+// gets the data format from a file extension, or an empty format if the extension is not recognised
+func formatOf(path string) DataFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	}
+	return ""
+}
+
+// loads and merges the GraphData in the specified YAML or JSON files and directories
+// directories are read recursively in lexical order, files can include other files using the $include key
+// both formats accept # comments
+func LoadData(paths ...string) (*GraphData, error) {
+	data := new(GraphData)
+	loading := make(map[string]loadState)
+	for _, path := range paths {
+		if err := loadPath(path, data, loading); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// reads GraphData in YAML or JSON format, $include keys are not supported
+func ReadData(r io.Reader) (*GraphData, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDoc(content)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc[includeKey]; ok {
+		return nil, fmt.Errorf("%s is only supported when loading files", includeKey)
+	}
+	return docToData(doc)
+}
+
+// writes the GraphData in the specified format
+// entities are sorted by key and empty fields are omitted in YAML, so that the output is deterministic and diffs cleanly
+func WriteData(w io.Writer, data *GraphData, format DataFormat) error {
+	sorted := data.sorted()
+	switch format {
+	case FormatJSON:
+		buffer := &bytes.Buffer{}
+		encoder := json.NewEncoder(buffer)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(sorted); err != nil {
+			return err
+		}
+		_, err := w.Write(buffer.Bytes())
+		return err
+	case FormatYAML:
+		doc, err := toOrderedDoc(sorted)
+		if err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+	return fmt.Errorf("data format '%s' not supported", format)
+}
+
+// saves the GraphData to a file, the format is worked out from the file extension
+func SaveData(path string, data *GraphData) error {
+	format := formatOf(path)
+	if len(format) == 0 {
+		return fmt.Errorf("cannot work out the data format of file '%s', use a .yaml, .yml or .json extension", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = WriteData(file, data, format)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// saves the GraphData to a directory, using one file per kind of entity (e.g. items.yaml, links.yaml)
+func SaveDataDir(dir string, data *GraphData, format DataFormat) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	parts := map[string]*GraphData{
+		"models":             {Models: data.Models},
+		"itemTypes":          {ItemTypes: data.ItemTypes},
+		"itemTypeAttributes": {ItemTypeAttributes: data.ItemTypeAttributes},
+		"linkTypes":          {LinkTypes: data.LinkTypes},
+		"linkTypeAttributes": {LinkTypeAttribute: data.LinkTypeAttribute},
+		"linkRules":          {LinkRules: data.LinkRules},
+		"items":              {Items: data.Items},
+		"links":              {Links: data.Links},
+	}
+	for name, part := range parts {
+		path := filepath.Join(dir, fmt.Sprintf("%s.%s", name, format))
+		if part.isEmpty() {
+			// removes any stale file from a previous save
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := SaveData(path, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the load state of a data file
+type loadState int
+
+const (
+	// the file is being loaded, i.e. it or the files it includes are being read
+	stateLoading loadState = iota + 1
+	// the file has been loaded
+	stateLoaded
+)
+
+// loads a file or directory into data
+// loading holds the state of the files seen so far, to load each file once and detect include cycles
+func loadPath(path string, data *GraphData, loading map[string]loadState) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return loadFile(abs, data, loading)
+	}
+	var files []string
+	err = filepath.Walk(abs, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() && len(formatOf(p)) > 0 {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if err = loadFile(file, data, loading); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loads a single file and the files it includes into data
+func loadFile(path string, data *GraphData, loading map[string]loadState) error {
+	switch loading[path] {
+	case stateLoading:
+		return fmt.Errorf("include cycle detected: file '%s' includes itself", path)
+	case stateLoaded:
+		// files are only loaded once, even if included by several files
+		return nil
+	}
+	loading[path] = stateLoading
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc, err := parseDoc(content)
+	if err != nil {
+		return fmt.Errorf("cannot parse data file '%s': %s", path, err)
+	}
+	if include, ok := doc[includeKey]; ok {
+		delete(doc, includeKey)
+		var includes []string
+		switch v := include.(type) {
+		case string:
+			includes = []string{v}
+		case []interface{}:
+			for _, i := range v {
+				includes = append(includes, fmt.Sprint(i))
+			}
+		default:
+			return fmt.Errorf("%s in file '%s' must be a path or a list of paths", includeKey, path)
+		}
+		for _, i := range includes {
+			if !filepath.IsAbs(i) {
+				i = filepath.Join(filepath.Dir(path), i)
+			}
+			if err = loadPath(i, data, loading); err != nil {
+				return fmt.Errorf("cannot include '%s' in file '%s': %s", i, path, err)
+			}
+		}
+	}
+	fileData, err := docToData(doc)
+	if err != nil {
+		return fmt.Errorf("cannot read data file '%s': %s", path, err)
+	}
+	data.merge(fileData)
+	loading[path] = stateLoaded
+	return nil
+}
+
+// parses a YAML or JSON document into a map
+func parseDoc(content []byte) (map[string]interface{}, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return map[string]interface{}{}, nil
+	}
+	doc, ok := toStringKeys(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the document root must be a map")
+	}
+	return doc, nil
+}
+
+// converts a parsed document into GraphData via its JSON representation, so that json tags apply
+func docToData(doc map[string]interface{}) (*GraphData, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	data := new(GraphData)
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	// rejects misspelt properties rather than silently ignoring them
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(data)
+	return data, err
+}
+
+// converts the map[interface{}]interface{} values produced by the yaml parser into map[string]interface{}
+func toStringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = toStringKeys(val)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = toStringKeys(v[i])
+		}
+	}
+	return value
+}
+
+// the nesting depth of the entity objects in a GraphData document: the document, the entity lists and the entities
+const entityDepth = 2
+
+// converts GraphData into a yaml document that keeps the JSON field order
+// empty entity fields are omitted, as they decode back to the same zero value, but values nested in
+// entity fields (e.g. attribute, meta, metaSchema or tag) are always kept so that no data is lost
+func toOrderedDoc(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	return readOrdered(decoder, 0)
+}
+
+// reads the next JSON value as a yaml.MapSlice, a slice or a scalar
+// depth: the nesting depth of the value, empty values are only omitted from objects up to the entity depth
+func readOrdered(decoder *json.Decoder, depth int) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			doc := yaml.MapSlice{}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := readOrdered(decoder, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				if depth > entityDepth || !isEmptyOrdered(value) {
+					doc = append(doc, yaml.MapItem{Key: key, Value: value})
+				}
+			}
+			_, err = decoder.Token()
+			return doc, err
+		}
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := readOrdered(decoder, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = decoder.Token()
+		return list, err
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	}
+	return token, nil
+}
+
+func isEmptyOrdered(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(v) == 0
+	case bool:
+		return !v
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case yaml.MapSlice:
+		return len(v) == 0
+	}
+	return false
+}
+
+// true if the graph data has no entities
+func (data *GraphData) isEmpty() bool {
+	return data.size() == 0
+}
+
+// gets a copy of the graph data with the entities sorted by key
+func (data *GraphData) sorted() *GraphData {
+	s := new(GraphData)
+	s.merge(data)
+	sort.SliceStable(s.Models, func(i, j int) bool { return s.Models[i].Key < s.Models[j].Key })
+	sort.SliceStable(s.ItemTypes, func(i, j int) bool { return s.ItemTypes[i].Key < s.ItemTypes[j].Key })
+	sort.SliceStable(s.ItemTypeAttributes, func(i, j int) bool {
+		a, b := s.ItemTypeAttributes[i], s.ItemTypeAttributes[j]
+		if a.ItemTypeKey != b.ItemTypeKey {
+			return a.ItemTypeKey < b.ItemTypeKey
+		}
+		return a.Key < b.Key
+	})
+	sort.SliceStable(s.LinkTypes, func(i, j int) bool { return s.LinkTypes[i].Key < s.LinkTypes[j].Key })
+	sort.SliceStable(s.LinkTypeAttribute, func(i, j int) bool {
+		a, b := s.LinkTypeAttribute[i], s.LinkTypeAttribute[j]
+		if a.LinkTypeKey != b.LinkTypeKey {
+			return a.LinkTypeKey < b.LinkTypeKey
+		}
+		return a.Key < b.Key
+	})
+	sort.SliceStable(s.LinkRules, func(i, j int) bool { return s.LinkRules[i].Key < s.LinkRules[j].Key })
+	sort.SliceStable(s.Items, func(i, j int) bool { return s.Items[i].Key < s.Items[j].Key })
+	sort.SliceStable(s.Links, func(i, j int) bool { return s.Links[i].Key < s.Links[j].Key })
+	return s
+}
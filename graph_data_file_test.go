@@ -0,0 +1,98 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// how to save graph data to a directory and load it back using includes
+func TestLoadData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oxc-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// saves the test data split by kind of entity
+	if err = SaveDataDir(filepath.Join(dir, "model"), getData(), FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+	// a hand written file including the model
+	seed := []byte(`# seed data for the terraform model
+$include: model/
+items:
+  - key: tf_state_1 # the state
+    name: State 1
+    type: TF_STATE
+    meta:
+      serial: 4
+`)
+	if err = ioutil.WriteFile(filepath.Join(dir, "seed.yaml"), seed, 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := LoadData(filepath.Join(dir, "seed.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.ItemTypes) != 2 || len(data.LinkRules) != 1 || len(data.Items) != 1 || data.Items[0].Meta["serial"] != float64(4) {
+		t.Fatalf("unexpected data loaded: %+v", data)
+	}
+	// the output must be stable
+	first, second := new(bytes.Buffer), new(bytes.Buffer)
+	if err = WriteData(first, data, FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := ReadData(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteData(second, reloaded, FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Fatalf("output is not deterministic:\n%s\n%s", first, second)
+	}
+}
+
+// checks that empty values nested in entity fields survive a round trip
+func TestWriteData_RoundTrip(t *testing.T) {
+	item := Item{
+		Key:       "item_1",
+		Name:      "Item 1",
+		Type:      "TF_STATE",
+		Attribute: map[string]interface{}{"replicas": float64(0), "enabled": false, "label": ""},
+		Meta:      map[string]interface{}{"on": false, "nested": map[string]interface{}{"count": float64(0)}},
+		Tag:       []interface{}{""},
+	}
+	for _, format := range []DataFormat{FormatYAML, FormatJSON} {
+		buffer := new(bytes.Buffer)
+		if err := WriteData(buffer, &GraphData{Items: []Item{item}}, format); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ReadData(buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data.Items) != 1 || !reflect.DeepEqual(data.Items[0], item) {
+			t.Fatalf("%s: item changed by the round trip:\n%+v\n%+v", format, item, data.Items)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the versions of an item, oldest first
+type ItemHistory struct {
+	Key      string
+	Versions []Item
+}
+
+// the changes made to an item between two versions
+type VersionDiff struct {
+	// the version the changes are made from
+	From int64 `json:"from"`
+	// the version the changes lead to
+	To int64 `json:"to"`
+	// who made the last change
+	ChangedBy string `json:"changedBy"`
+	// when the last change was made
+	Updated string `json:"updated"`
+	// the field changes
+	Changes []FieldChange `json:"changes"`
+}
+
+// decodes the versions of an item returned by the Web API and sorts them by version
+func decodeItemHistory(key string, response *http.Response) (*ItemHistory, error) {
+	list := new(ItemList)
+	err := json.NewDecoder(response.Body).Decode(list)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list.Values, func(i, j int) bool { return list.Values[i].Version < list.Values[j].Version })
+	return &ItemHistory{Key: key, Versions: list.Values}, nil
+}
+
+// the URI of the change history of an item
+func uriItemHistory(baseUrl, key string) (string, error) {
+	if len(key) == 0 {
+		return "", fmt.Errorf("the item does not have a key: cannot construct Item/Changes resource URI")
+	}
+	return fmt.Sprintf("%s/item/%s/change", baseUrl, key), nil
+}
+
+// the latest version of the item, nil if the history is empty
+func (h *ItemHistory) Latest() *Item {
+	if len(h.Versions) == 0 {
+		return nil
+	}
+	return &h.Versions[len(h.Versions)-1]
+}
+
+// the item at the specified version, nil if the version is not in the history
+func (h *ItemHistory) Version(version int64) *Item {
+	for i := range h.Versions {
+		if h.Versions[i].Version == version {
+			return &h.Versions[i]
+		}
+	}
+	return nil
+}
+
+// the item as it was at the specified time, nil if it did not exist yet
+func (h *ItemHistory) AsOf(t time.Time) (*Item, error) {
+	var item *Item
+	for i := range h.Versions {
+		changed, err := versionTime(&h.Versions[i])
+		if err != nil {
+			return nil, err
+		}
+		if changed.After(t) {
+			break
+		}
+		item = &h.Versions[i]
+	}
+	return item, nil
+}
+
+// the field changes between two versions, the fields managed by the Web API are not compared
+func (h *ItemHistory) Diff(from, to int64) (*VersionDiff, error) {
+	fromItem, toItem := h.Version(from), h.Version(to)
+	if fromItem == nil {
+		return nil, fmt.Errorf("item '%s' has no version %d", h.Key, from)
+	}
+	if toItem == nil {
+		return nil, fmt.Errorf("item '%s' has no version %d", h.Key, to)
+	}
+	return diffVersions(fromItem, toItem)
+}
+
+// the changes made in each version, oldest first
+// for example, to find out who changed an attribute and when, look for its path in the changes
+func (h *ItemHistory) Changes() ([]VersionDiff, error) {
+	var diffs []VersionDiff
+	for i := 1; i < len(h.Versions); i++ {
+		diff, err := diffVersions(&h.Versions[i-1], &h.Versions[i])
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, *diff)
+	}
+	return diffs, nil
+}
+
+// the changes made to a field (e.g. /attribute/ip) in each version, oldest first
+func (h *ItemHistory) FieldChanges(path string) ([]VersionDiff, error) {
+	diffs, err := h.Changes()
+	if err != nil {
+		return nil, err
+	}
+	var result []VersionDiff
+	for _, diff := range diffs {
+		var changes []FieldChange
+		for _, change := range diff.Changes {
+			if change.Path == path || strings.HasPrefix(change.Path, path+"/") {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) > 0 {
+			diff.Changes = changes
+			result = append(result, diff)
+		}
+	}
+	return result, nil
+}
+
+// compares two versions of an item
+func diffVersions(from, to *Item) (*VersionDiff, error) {
+	source, err := toFieldMap(from)
+	if err != nil {
+		return nil, err
+	}
+	target, err := toFieldMap(to)
+	if err != nil {
+		return nil, err
+	}
+	for field := range serverFields {
+		delete(source, field)
+		delete(target, field)
+	}
+	return &VersionDiff{
+		From:      from.Version,
+		To:        to.Version,
+		ChangedBy: to.ChangedBy,
+		Updated:   to.Updated,
+		Changes:   diffValues("", source, target, nil),
+	}, nil
+}
+
+// the time a version was made: its update time or, for the first version, its creation time
+func versionTime(item *Item) (time.Time, error) {
+	return changedTime(item.Created, item.Updated)
+}
@@ -0,0 +1,57 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"testing"
+	"time"
+)
+
+// checks time-travel reads and version diffs
+func TestItemHistory(t *testing.T) {
+	history := &ItemHistory{
+		Key: "server_1",
+		Versions: []Item{
+			{Key: "server_1", Version: 1, Attribute: map[string]interface{}{"ip": "10.0.0.1"}, Created: "01-06-2020 09:00:00+0000", ChangedBy: "alice"},
+			{Key: "server_1", Version: 2, Attribute: map[string]interface{}{"ip": "10.0.0.1", "os": "rhel"}, Created: "01-06-2020 09:00:00+0000", Updated: "02-06-2020 10:30:00+0000", ChangedBy: "bob"},
+			{Key: "server_1", Version: 3, Attribute: map[string]interface{}{"ip": "10.0.0.2", "os": "rhel"}, Created: "01-06-2020 09:00:00+0000", Updated: "03-06-2020 08:15:00+0100", ChangedBy: "carol"},
+		},
+	}
+	item, err := history.AsOf(time.Date(2020, 6, 2, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item == nil || item.Version != 2 {
+		t.Fatalf("expected version 2, got: %+v", item)
+	}
+	if item, _ = history.AsOf(time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)); item != nil {
+		t.Fatalf("expected no item before creation, got: %+v", item)
+	}
+	diff, err := history.Diff(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 2 || diff.ChangedBy != "carol" {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	changes, err := history.FieldChanges("/attribute/ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].ChangedBy != "carol" || changes[0].Changes[0].To != "10.0.0.2" {
+		t.Fatalf("unexpected ip changes: %+v", changes)
+	}
+}
@@ -0,0 +1,301 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+var (
+	// returned by the typed attribute accessors when the attribute does not exist
+	ErrAttrMissing = errors.New("attribute is missing")
+	// returned by the typed attribute accessors when the attribute value cannot be converted to the requested type
+	ErrAttrType = errors.New("attribute has the wrong type")
+)
+
+// AttrString return the value of the string attribute for the specified name
+func (item *Item) AttrString(name string) (string, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := attr.(string)
+	if !ok {
+		return "", attrTypeError(name, "string", attr)
+	}
+	return value, nil
+}
+
+// AttrBool return the value of the boolean attribute for the specified name
+func (item *Item) AttrBool(name string) (bool, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return false, err
+	}
+	value, ok := attr.(bool)
+	if !ok {
+		return false, attrTypeError(name, "boolean", attr)
+	}
+	return value, nil
+}
+
+// AttrInt return the value of the integer attribute for the specified name
+// JSON numbers are accepted as long as they have no fractional part
+func (item *Item) AttrInt(name string) (int64, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := toInt64(attr)
+	if !ok {
+		return 0, attrTypeError(name, "integer", attr)
+	}
+	return value, nil
+}
+
+// AttrFloat return the value of the numeric attribute for the specified name
+func (item *Item) AttrFloat(name string) (float64, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := toFloat64(attr)
+	if !ok {
+		return 0, attrTypeError(name, "number", attr)
+	}
+	return value, nil
+}
+
+// AttrTime return the value of the date attribute for the specified name
+// the value can be a time.Time or a string in any of the formats accepted by ParseTimestamp
+func (item *Item) AttrTime(name string) (time.Time, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch v := attr.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if t, err := ParseTimestamp(v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, attrTypeError(name, "date", attr)
+}
+
+// AttrDuration return the value of the duration attribute for the specified name
+// the value can be a string such as "1h30m" or an integer number of nanoseconds (as time.Duration is serialised to JSON)
+func (item *Item) AttrDuration(name string) (time.Duration, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return 0, err
+	}
+	if s, ok := attr.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+	} else if d, ok := attr.(time.Duration); ok {
+		return d, nil
+	} else if n, ok := toInt64(attr); ok {
+		return time.Duration(n), nil
+	}
+	return 0, attrTypeError(name, "duration", attr)
+}
+
+// AttrList return the value of the list attribute for the specified name
+func (item *Item) AttrList(name string) ([]interface{}, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := attr.([]interface{}); ok {
+		return list, nil
+	}
+	// converts slices of other types set by go code
+	value := reflect.ValueOf(attr)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, attrTypeError(name, "list", attr)
+	}
+	list := make([]interface{}, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		list[i] = value.Index(i).Interface()
+	}
+	return list, nil
+}
+
+// AttrStringList return the value of the list of strings attribute for the specified name
+func (item *Item) AttrStringList(name string) ([]string, error) {
+	list, err := item.AttrList(name)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(list))
+	for i, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, attrTypeError(name, "list of strings", list)
+		}
+		result[i] = s
+	}
+	return result, nil
+}
+
+// AttrMap return the value of the map attribute for the specified name
+func (item *Item) AttrMap(name string) (map[string]interface{}, error) {
+	attr, err := item.attr(name)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := attr.(map[string]interface{})
+	if !ok {
+		return nil, attrTypeError(name, "map", attr)
+	}
+	return value, nil
+}
+
+// DecodeAttributes copies the item attributes into the passed-in struct pointer, using its json struct tags
+func (item *Item) DecodeAttributes(target interface{}) error {
+	return decodeMap(item.Attribute, target)
+}
+
+// EncodeAttributes copies the fields of the passed-in struct into the item attributes, using its json struct tags
+// attributes not represented by the struct are kept
+func (item *Item) EncodeAttributes(source interface{}) error {
+	attrs, err := encodeMap(item.Attribute, source)
+	if err != nil {
+		return err
+	}
+	item.Attribute = attrs
+	return nil
+}
+
+// DecodeMeta copies the item meta into the passed-in struct pointer, using its json struct tags
+func (item *Item) DecodeMeta(target interface{}) error {
+	return decodeMap(item.Meta, target)
+}
+
+// EncodeMeta copies the fields of the passed-in struct into the item meta, using its json struct tags
+// meta properties not represented by the struct are kept
+func (item *Item) EncodeMeta(source interface{}) error {
+	meta, err := encodeMap(item.Meta, source)
+	if err != nil {
+		return err
+	}
+	item.Meta = meta
+	return nil
+}
+
+// gets the attribute with the specified name or an error if it does not exist
+func (item *Item) attr(name string) (interface{}, error) {
+	attr, exists := item.Attribute[name]
+	if !exists || attr == nil {
+		return nil, fmt.Errorf("item '%s' attribute '%s': %w", item.Key, name, ErrAttrMissing)
+	}
+	return attr, nil
+}
+
+func attrTypeError(name, expected string, value interface{}) error {
+	return fmt.Errorf("attribute '%s' value '%v' of type %T is not a %s: %w", name, value, value, expected, ErrAttrType)
+}
+
+// converts a map into a struct via its JSON representation
+func decodeMap(source map[string]interface{}, target interface{}) error {
+	b, err := json.Marshal(source)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, target)
+}
+
+// merges the JSON representation of a struct into a map
+// numbers are kept as json.Number so that large integers do not lose precision
+func encodeMap(target map[string]interface{}, source interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err = decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("cannot encode %T: %s", source, err)
+	}
+	if target == nil {
+		target = make(map[string]interface{}, len(values))
+	}
+	for k, v := range values {
+		target[k] = v
+	}
+	return target, nil
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case float32:
+		return toInt64(float64(v))
+	case float64:
+		// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+		return int64(v), v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	if n, ok := toInt64(value); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
@@ -0,0 +1,115 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// how to use the typed attribute accessors and struct mapping
+func TestItem_Attributes(t *testing.T) {
+	item := &Item{
+		Key: "host_1",
+		Attribute: map[string]interface{}{
+			"cpu":     float64(4),
+			"ttl":     "1h30m",
+			"created": "2020-05-01T10:00:00Z",
+			"ip":      "10.0.0.1",
+		},
+	}
+	if cpu, err := item.AttrInt("cpu"); err != nil || cpu != 4 {
+		t.Fatalf("unexpected cpu value %v: %v", cpu, err)
+	}
+	if ttl, err := item.AttrDuration("ttl"); err != nil || ttl != 90*time.Minute {
+		t.Fatalf("unexpected ttl value %v: %v", ttl, err)
+	}
+	if _, err := item.AttrTime("created"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := item.AttrInt("ip"); !errors.Is(err, ErrAttrType) {
+		t.Fatalf("expected wrong type error, got: %v", err)
+	}
+	if _, err := item.AttrString("ram"); !errors.Is(err, ErrAttrMissing) {
+		t.Fatalf("expected missing error, got: %v", err)
+	}
+	// maps attributes to and from a struct
+	host := struct {
+		CPU int    `json:"cpu"`
+		IP  string `json:"ip"`
+	}{}
+	if err := item.DecodeAttributes(&host); err != nil {
+		t.Fatal(err)
+	}
+	host.CPU = 8
+	if err := item.EncodeAttributes(host); err != nil {
+		t.Fatal(err)
+	}
+	if cpu, _ := item.AttrInt("cpu"); cpu != 8 || item.Attribute["ttl"] != "1h30m" {
+		t.Fatalf("unexpected attributes after encoding: %v", item.Attribute)
+	}
+}
+
+// checks that whole numbers outside the int64 range are not converted
+func TestToInt64_Range(t *testing.T) {
+	cases := map[interface{}]bool{
+		float64(1e18):     true,
+		float64(-1 << 63): true,
+		float64(1 << 63):  false,
+		float64(1e20):     false,
+		float64(-1e20):    false,
+		float32(1e20):     false,
+		float64(1.5):      false,
+		uint64(1<<63 - 1): true,
+		uint64(1 << 63):   false,
+	}
+	for value, expected := range cases {
+		if _, ok := toInt64(value); ok != expected {
+			t.Errorf("%T %v: expected %v, got %v", value, value, expected, ok)
+		}
+	}
+	item := &Item{Key: "item_1", Attribute: map[string]interface{}{"big": 1e20}}
+	if _, err := item.AttrInt("big"); !errors.Is(err, ErrAttrType) {
+		t.Fatalf("expected wrong type error, got: %v", err)
+	}
+}
+
+// checks that date attributes accept the timestamp layouts used by the Web API
+func TestItem_AttrTime(t *testing.T) {
+	item := &Item{Key: "item_1", Attribute: map[string]interface{}{
+		"server": "01-05-2020 10:00:00+0100",
+		"rfc":    "2020-05-01T09:00:00Z",
+		"day":    "2020-05-01",
+	}}
+	server, err := item.AttrTime("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfc, err := item.AttrTime("rfc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.Equal(rfc) {
+		t.Fatalf("expected %s to equal %s", server, rfc)
+	}
+	if _, err = item.AttrTime("day"); err != nil {
+		t.Fatal(err)
+	}
+	if err = checkAttrType("date", item.Attribute["server"]); err != nil {
+		t.Fatal(err)
+	}
+}
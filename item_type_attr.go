@@ -53,6 +53,13 @@ func (typeAttr *ItemTypeAttribute) decode(response *http.Response) (*ItemTypeAtt
 	return result, err
 }
 
+// Get the ItemTypeAttributeList in the http Response
+func decodeItemTypeAttributeList(response *http.Response) (*ItemTypeAttributeList, error) {
+	result := new(ItemTypeAttributeList)
+	err := json.NewDecoder(response.Body).Decode(result)
+	return result, err
+}
+
 // Get the FQN for the item resource
 func (typeAttr *ItemTypeAttribute) uri(baseUrl string) (string, error) {
 	if len(typeAttr.ItemTypeKey) == 0 {
@@ -88,3 +95,11 @@ func (typeAttr *ItemTypeAttribute) valid() error {
 	}
 	return nil
 }
+
+// Get the FQN for the list of attributes of an item type
+func uriItemTypeAttributes(baseUrl, itemTypeKey string) (string, error) {
+	if len(itemTypeKey) == 0 {
+		return "", fmt.Errorf("the item type key is missing: cannot construct itemtype attr list resource URI")
+	}
+	return fmt.Sprintf("%s/itemtype/%s/attribute", baseUrl, itemTypeKey), nil
+}
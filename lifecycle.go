@@ -0,0 +1,203 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// the error returned when an item status change is not allowed by its lifecycle
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// checks whether an item can move to a new status, returning an error to prevent it
+// current: the item as stored by the Web API
+// next: the item about to be stored
+type TransitionGuard func(current, next *Item) error
+
+// the named states an item of a type can be in and the allowed transitions between them
+// for example:
+//   NewLifecycle("HOST").
+//     State(0, "ordered").State(1, "active").State(2, "retired").
+//     Initial("ordered").
+//     Allow("ordered", "active", nil).
+//     Allow("active", "retired", guard)
+type Lifecycle struct {
+	itemType    string
+	names       map[int]string
+	statuses    map[string]int
+	initial     map[int]bool
+	transitions map[int]map[int]TransitionGuard
+	// the first definition error found, reported when the lifecycle is used
+	err error
+}
+
+// creates an empty lifecycle for an item type
+func NewLifecycle(itemType string) *Lifecycle {
+	return &Lifecycle{
+		itemType:    itemType,
+		names:       make(map[int]string),
+		statuses:    make(map[string]int),
+		initial:     make(map[int]bool),
+		transitions: make(map[int]map[int]TransitionGuard),
+	}
+}
+
+// the item type the lifecycle applies to
+func (l *Lifecycle) ItemType() string {
+	return l.itemType
+}
+
+// defines a named state for a status value
+func (l *Lifecycle) State(status int, name string) *Lifecycle {
+	if _, exists := l.statuses[name]; exists {
+		l.fail("state '%s' is defined more than once", name)
+	}
+	if _, exists := l.names[status]; exists {
+		l.fail("status %d is defined more than once", status)
+	}
+	l.names[status] = name
+	l.statuses[name] = status
+	return l
+}
+
+// sets the states new items can be created in, by default any state is allowed
+func (l *Lifecycle) Initial(names ...string) *Lifecycle {
+	for _, name := range names {
+		if status, ok := l.statuses[name]; ok {
+			l.initial[status] = true
+		} else {
+			l.fail("initial state '%s' is not defined", name)
+		}
+	}
+	return l
+}
+
+// allows items to move from one state to another
+// guard: an optional check the items must pass, can be nil
+func (l *Lifecycle) Allow(from, to string, guard TransitionGuard) *Lifecycle {
+	fromStatus, ok := l.statuses[from]
+	if !ok {
+		l.fail("state '%s' is not defined", from)
+		return l
+	}
+	toStatus, ok := l.statuses[to]
+	if !ok {
+		l.fail("state '%s' is not defined", to)
+		return l
+	}
+	if l.transitions[fromStatus] == nil {
+		l.transitions[fromStatus] = make(map[int]TransitionGuard)
+	}
+	l.transitions[fromStatus][toStatus] = guard
+	return l
+}
+
+// the name of the state for a status value
+func (l *Lifecycle) StateName(status int) (string, bool) {
+	name, ok := l.names[status]
+	return name, ok
+}
+
+// the status value of a named state
+func (l *Lifecycle) Status(name string) (int, bool) {
+	status, ok := l.statuses[name]
+	return status, ok
+}
+
+// the names of the states an item can move to from the specified state, sorted by name
+// returns nil if the state is not defined
+func (l *Lifecycle) Transitions(from string) []string {
+	fromStatus, ok := l.statuses[from]
+	if !ok {
+		return nil
+	}
+	var names []string
+	for status := range l.transitions[fromStatus] {
+		names = append(names, l.names[status])
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checks that an item can be stored
+// current: the item as stored by the Web API, nil if the item is being created
+// next: the item about to be stored
+func (l *Lifecycle) Check(current, next *Item) error {
+	if l.err != nil {
+		return l.err
+	}
+	// only status changes are checked, so items in a status outside the lifecycle can still be updated
+	if current != nil && current.Status == next.Status {
+		return nil
+	}
+	to, ok := l.names[next.Status]
+	if !ok {
+		return l.transitionError(next, "", fmt.Sprint(next.Status), "status %d is not a state of the lifecycle", next.Status)
+	}
+	if current == nil {
+		if len(l.initial) > 0 && !l.initial[next.Status] {
+			return l.transitionError(next, "", to, "items cannot be created in state '%s'", to)
+		}
+		return nil
+	}
+	from, ok := l.names[current.Status]
+	if !ok {
+		from = fmt.Sprint(current.Status)
+	}
+	guard, allowed := l.transitions[current.Status][next.Status]
+	if !allowed {
+		return l.transitionError(next, from, to, "moving from '%s' to '%s' is not allowed", from, to)
+	}
+	if guard != nil {
+		if err := guard(current, next); err != nil {
+			return l.transitionError(next, from, to, "%s", err)
+		}
+	}
+	return nil
+}
+
+// records a definition error
+func (l *Lifecycle) fail(format string, args ...interface{}) {
+	if l.err == nil {
+		l.err = fmt.Errorf("invalid lifecycle for item type '%s': %s", l.itemType, fmt.Sprintf(format, args...))
+	}
+}
+
+func (l *Lifecycle) transitionError(item *Item, from, to, reason string, args ...interface{}) error {
+	return &TransitionError{ItemType: l.itemType, Key: item.Key, From: from, To: to, Reason: fmt.Sprintf(reason, args...)}
+}
+
+// the error returned when an item status change is not allowed
+type TransitionError struct {
+	ItemType string
+	Key      string
+	// the current state name, empty if the item is being created
+	From string
+	// the requested state name
+	To string
+	// why the change is not allowed
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("item '%s' of type '%s': %s", e.Key, e.ItemType, e.Reason)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
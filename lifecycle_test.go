@@ -0,0 +1,102 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"testing"
+)
+
+func hostLifecycle() *Lifecycle {
+	return NewLifecycle("HOST").
+		State(0, "ordered").State(1, "active").State(2, "retired").
+		Initial("ordered").
+		Allow("ordered", "active", nil).
+		Allow("active", "retired", func(current, next *Item) error {
+			if next.GetBoolAttr("in_use") {
+				return errors.New("the host is still in use")
+			}
+			return nil
+		})
+}
+
+// checks the allowed transitions and guards
+func TestLifecycle_Check(t *testing.T) {
+	l := hostLifecycle()
+	if err := l.Check(nil, &Item{Key: "host_1", Status: 1}); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected creation in a non initial state to fail, got: %v", err)
+	}
+	if err := l.Check(&Item{Status: 0}, &Item{Key: "host_1", Status: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Check(&Item{Status: 0}, &Item{Key: "host_1", Status: 2}); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ordered -> retired to fail, got: %v", err)
+	}
+	inUse := &Item{Key: "host_1", Status: 2, Attribute: map[string]interface{}{"in_use": true}}
+	var transitionErr *TransitionError
+	if err := l.Check(&Item{Status: 1}, inUse); !errors.As(err, &transitionErr) || transitionErr.From != "active" {
+		t.Fatalf("expected the guard to fail, got: %v", err)
+	}
+	if err := NewLifecycle("HOST").State(0, "a").Allow("a", "b", nil).Check(nil, &Item{}); err == nil {
+		t.Fatal("expected a definition error")
+	}
+	// items in a status outside the lifecycle can be updated as long as the status does not change
+	if err := l.Check(&Item{Status: 9}, &Item{Key: "host_1", Status: 9}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Check(&Item{Status: 9}, &Item{Key: "host_1", Status: 8}); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected a change to a status outside the lifecycle to fail, got: %v", err)
+	}
+}
+
+// checks the transitions available from each state
+func TestLifecycle_Transitions(t *testing.T) {
+	l := hostLifecycle()
+	if next := l.Transitions("ordered"); len(next) != 1 || next[0] != "active" {
+		t.Fatalf("unexpected transitions from 'ordered': %v", next)
+	}
+	if next := l.Transitions("retired"); len(next) != 0 {
+		t.Fatalf("unexpected transitions from 'retired': %v", next)
+	}
+	if next := l.Transitions("nope"); next != nil {
+		t.Fatalf("expected no transitions from an undefined state, got: %v", next)
+	}
+}
+
+// checks that the client enforces the lifecycle when writing items
+func TestClient_TransitionItem(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.SetLifecycle("HOST", hostLifecycle()); err != nil {
+		t.Fatal(err)
+	}
+	items["host_1"] = Item{Key: "host_1", Name: "Host 1", Type: "HOST", Status: 0}
+	if _, err = c.TransitionItem("host_1", "retired"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ordered -> retired to fail, got: %v", err)
+	}
+	result, err := c.TransitionItem("host_1", "active")
+	checkResult(result, err, "cannot activate host", t)
+	if state := c.ItemState(&Item{Type: "HOST", Status: items["host_1"].Status}); state != "active" {
+		t.Fatalf("expected the host to be active, got: '%s'", state)
+	}
+	if _, err = c.PatchItem("host_1", NewItemPatch().SetStatus(0)); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected active -> ordered to fail, got: %v", err)
+	}
+}
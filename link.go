@@ -53,6 +53,13 @@ func (link *Link) decode(response *http.Response) (*Link, error) {
 	return result, err
 }
 
+// Get the LinkList in the http Response
+func decodeLinkList(response *http.Response) (*LinkList, error) {
+	result := new(LinkList)
+	err := json.NewDecoder(response.Body).Decode(result)
+	return result, err
+}
+
 // Get the FQN for the link type resource
 func (link *Link) uri(baseUrl string) (string, error) {
 	if len(link.Key) == 0 {
@@ -53,6 +53,13 @@ func (typeAttr *LinkTypeAttribute) decode(response *http.Response) (*LinkTypeAtt
 	return result, err
 }
 
+// Get the LinkTypeAttributeList in the http Response
+func decodeLinkTypeAttributeList(response *http.Response) (*LinkTypeAttributeList, error) {
+	result := new(LinkTypeAttributeList)
+	err := json.NewDecoder(response.Body).Decode(result)
+	return result, err
+}
+
 // Get the FQN for the item type attribute resource
 func (typeAttr *LinkTypeAttribute) uri(baseUrl string) (string, error) {
 	if len(typeAttr.LinkTypeKey) == 0 {
@@ -88,3 +95,11 @@ func (typeAttr *LinkTypeAttribute) valid() error {
 	}
 	return nil
 }
+
+// Get the FQN for the list of attributes of a link type
+func uriLinkTypeAttributes(baseUrl, linkTypeKey string) (string, error) {
+	if len(linkTypeKey) == 0 {
+		return "", fmt.Errorf("the link type key is missing: cannot construct linktype attr list resource URI")
+	}
+	return fmt.Sprintf("%s/linktype/%s/attribute", baseUrl, linkTypeKey), nil
+}
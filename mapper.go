@@ -0,0 +1,459 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// the name of the struct tag used to map go structs to items
+// the following tag values are supported:
+//   type:<item type>  - on a blank (_) field, the item type of the struct
+//   key               - the item key (required)
+//   name, description, status, partition, txt, version - the equivalent item fields
+//   tag               - the item tags (e.g. []string)
+//   attr[:<name>]     - an item attribute, the name defaults to the lower case field name
+//   meta[:<name>]     - the whole item meta (struct or map) or a named meta property
+//   link:<link type>  - a pointer or slice of pointers to related structs, saved as links of the specified type
+// for example:
+//   type Host struct {
+//	     _    struct{} `oxc:"type:HOST"`
+//	     Key  string   `oxc:"key"`
+//	     Name string   `oxc:"name"`
+//	     CPU  int      `oxc:"attr:cpu"`
+//	     Rack *Rack    `oxc:"link:HOST_RACK"`
+//   }
+const mapperTag = "oxc"
+
+// saves and loads go structs annotated with oxc struct tags as items and links
+type Mapper struct {
+	client *Client
+}
+
+// creates a new mapper using the passed-in client to access the Web API
+func NewMapper(client *Client) *Mapper {
+	return &Mapper{client: client}
+}
+
+// saves the passed-in struct as an item, along with any related structs and the links to them
+func (m *Mapper) Save(obj interface{}) error {
+	return m.save(reflect.ValueOf(obj), make(map[string]bool))
+}
+
+// loads the item with the specified key into the passed-in struct pointer
+// related structs are loaded from the first level children of the item linked by the link type of each field
+func (m *Mapper) Load(key string, target interface{}) error {
+	item, err := m.client.GetItem(&Item{Key: key})
+	if err != nil {
+		return err
+	}
+	if err = UnmarshalItem(item, target); err != nil {
+		return err
+	}
+	return m.loadLinks(item, reflect.ValueOf(target))
+}
+
+// loads all items of the struct item type into the passed-in pointer to a slice of structs or struct pointers
+// related structs are not loaded
+func (m *Mapper) LoadAll(target interface{}) error {
+	slice := reflect.ValueOf(target)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("target must be a pointer to a slice, found %T", target)
+	}
+	elemType := slice.Elem().Type().Elem()
+	mapping, err := mappingOf(elemType)
+	if err != nil {
+		return err
+	}
+	list, err := m.client.GetItemsByType(mapping.itemType)
+	if err != nil {
+		return err
+	}
+	return unmarshalItems(list, slice.Elem())
+}
+
+// saves the struct and its relations, visited holds the keys of the items already saved
+func (m *Mapper) save(value reflect.Value, visited map[string]bool) error {
+	item, err := marshalItem(value)
+	if err != nil {
+		return err
+	}
+	if visited[item.Key] {
+		return nil
+	}
+	visited[item.Key] = true
+	if err = checkPut(m.client.PutItem(item)); err != nil {
+		return fmt.Errorf("cannot save item '%s': %w", item.Key, err)
+	}
+	mapping, _ := mappingOf(value.Type())
+	for _, f := range mapping.links {
+		for _, related := range relatedValues(reflect.Indirect(value).Field(f.index)) {
+			if err = m.save(related, visited); err != nil {
+				return err
+			}
+			relatedItem, err := marshalItem(related)
+			if err != nil {
+				return err
+			}
+			link := &Link{
+				Key:          LinkKey(item.Key, relatedItem.Key, f.name),
+				Type:         f.name,
+				StartItemKey: item.Key,
+				EndItemKey:   relatedItem.Key,
+			}
+			if err = checkPut(m.client.PutLink(link)); err != nil {
+				return fmt.Errorf("cannot save link '%s': %w", link.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// the key of the link of the specified type between two items, as used by the mapper and the code generated by oxc-gen
+func LinkKey(startItemKey, endItemKey, linkType string) string {
+	return fmt.Sprintf("%s->%s:%s", startItemKey, endItemKey, linkType)
+}
+
+// populates the link fields of the target with the children of the item linked by the field link type
+func (m *Mapper) loadLinks(item *Item, target reflect.Value) error {
+	mapping, err := mappingOf(target.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range mapping.links {
+		field := target.Elem().Field(f.index)
+		relatedType := field.Type()
+		if relatedType.Kind() == reflect.Slice {
+			relatedType = relatedType.Elem()
+		}
+		related, err := mappingOf(relatedType)
+		if err != nil {
+			return err
+		}
+		list, err := m.client.GetChildrenByType(item, related.itemType)
+		if err != nil {
+			return err
+		}
+		if list, err = m.linkedBy(item, list, f.name); err != nil {
+			return err
+		}
+		if field.Kind() == reflect.Slice {
+			if err = unmarshalItems(list, field); err != nil {
+				return err
+			}
+		} else if len(list.Values) > 0 {
+			value := reflect.New(relatedType.Elem())
+			if err = UnmarshalItem(&list.Values[0], value.Interface()); err != nil {
+				return err
+			}
+			field.Set(value)
+		}
+	}
+	return nil
+}
+
+// filters the children of an item, keeping the ones linked to the item by a link of the specified type
+func (m *Mapper) linkedBy(item *Item, children *ItemList, linkType string) (*ItemList, error) {
+	if len(children.Values) == 0 {
+		return children, nil
+	}
+	links, err := m.client.GetLinksByType(linkType)
+	if err != nil {
+		return nil, err
+	}
+	ends := make(map[string]bool)
+	for _, link := range links.Values {
+		if link.StartItemKey == item.Key {
+			ends[link.EndItemKey] = true
+		}
+	}
+	linked := new(ItemList)
+	for _, child := range children.Values {
+		if ends[child.Key] {
+			linked.Values = append(linked.Values, child)
+		}
+	}
+	return linked, nil
+}
+
+// converts a struct annotated with oxc tags into an item
+func MarshalItem(obj interface{}) (*Item, error) {
+	return marshalItem(reflect.ValueOf(obj))
+}
+
+// populates a struct pointer annotated with oxc tags from an item
+// link fields are not populated
+func UnmarshalItem(item *Item, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("target must be a non nil pointer to a struct, found %T", target)
+	}
+	mapping, err := mappingOf(value.Type())
+	if err != nil {
+		return err
+	}
+	s := value.Elem()
+	for _, f := range mapping.fields {
+		var source interface{}
+		switch f.kind {
+		case "key":
+			source = item.Key
+		case "name":
+			source = item.Name
+		case "description":
+			source = item.Description
+		case "status":
+			source = item.Status
+		case "partition":
+			source = item.Partition
+		case "txt":
+			source = item.Txt
+		case "version":
+			source = item.Version
+		case "tag":
+			source = item.Tag
+		case "attr":
+			source = item.Attribute[f.name]
+		case "meta":
+			if len(f.name) == 0 {
+				source = item.Meta
+			} else {
+				source = item.Meta[f.name]
+			}
+		}
+		if source == nil {
+			continue
+		}
+		if err = setField(s.Field(f.index), source); err != nil {
+			return fmt.Errorf("cannot set field '%s' from item '%s': %s", s.Type().Field(f.index).Name, item.Key, err)
+		}
+	}
+	return nil
+}
+
+func marshalItem(value reflect.Value) (*Item, error) {
+	mapping, err := mappingOf(value.Type())
+	if err != nil {
+		return nil, err
+	}
+	s := reflect.Indirect(value)
+	if !s.IsValid() {
+		return nil, fmt.Errorf("cannot marshal a nil %s", value.Type())
+	}
+	item := &Item{Type: mapping.itemType}
+	for _, f := range mapping.fields {
+		field := s.Field(f.index)
+		switch f.kind {
+		case "key":
+			item.Key = fmt.Sprint(field.Interface())
+		case "name":
+			item.Name = fmt.Sprint(field.Interface())
+		case "description":
+			item.Description = fmt.Sprint(field.Interface())
+		case "partition":
+			item.Partition = fmt.Sprint(field.Interface())
+		case "txt":
+			item.Txt = fmt.Sprint(field.Interface())
+		case "status", "version":
+			v, err := fieldValue(field)
+			if err != nil {
+				return nil, err
+			}
+			n, ok := toInt64(v)
+			if !ok {
+				return nil, fmt.Errorf("%s field '%s' must be an integer", s.Type(), s.Type().Field(f.index).Name)
+			}
+			if f.kind == "status" {
+				item.Status = int(n)
+			} else {
+				item.Version = n
+			}
+		case "tag":
+			v, err := fieldValue(field)
+			if err != nil {
+				return nil, err
+			}
+			if tags, ok := v.([]interface{}); ok {
+				item.Tag = tags
+			}
+		case "attr":
+			v, err := fieldValue(field)
+			if err != nil {
+				return nil, err
+			}
+			if item.Attribute == nil {
+				item.Attribute = make(map[string]interface{})
+			}
+			item.Attribute[f.name] = v
+		case "meta":
+			v, err := fieldValue(field)
+			if err != nil {
+				return nil, err
+			}
+			if len(f.name) == 0 {
+				if meta, ok := v.(map[string]interface{}); ok {
+					item.Meta = meta
+				}
+				continue
+			}
+			if item.Meta == nil {
+				item.Meta = make(map[string]interface{})
+			}
+			item.Meta[f.name] = v
+		}
+	}
+	if len(item.Key) == 0 {
+		return nil, fmt.Errorf("%s does not have a key value", s.Type())
+	}
+	// the item name is mandatory, so defaults it to the key
+	if len(item.Name) == 0 {
+		item.Name = item.Key
+	}
+	return item, nil
+}
+
+// unmarshal the items in a list into a slice of structs or struct pointers
+func unmarshalItems(list *ItemList, slice reflect.Value) error {
+	elemType := slice.Type().Elem()
+	result := reflect.MakeSlice(slice.Type(), 0, len(list.Values))
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	for i := range list.Values {
+		value := reflect.New(structType)
+		if err := UnmarshalItem(&list.Values[i], value.Interface()); err != nil {
+			return err
+		}
+		if isPtr {
+			result = reflect.Append(result, value)
+		} else {
+			result = reflect.Append(result, value.Elem())
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// a struct field mapped to an item
+type mappedField struct {
+	index int
+	// key, name, description, status, partition, txt, version, tag, attr, meta or link
+	kind string
+	// the attribute, meta property or link type name
+	name string
+}
+
+// the mapping between a struct type and an item type
+type structMapping struct {
+	itemType string
+	fields   []mappedField
+	links    []mappedField
+}
+
+// works out the mapping for a struct (or pointer to struct) type from its oxc tags
+func mappingOf(t reflect.Type) (*structMapping, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+	mapping := new(structMapping)
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup(mapperTag)
+		if !ok || tag == "-" {
+			continue
+		}
+		parts := strings.SplitN(tag, ":", 2)
+		// only the item type can be set on an unexported (i.e. blank) field
+		if len(t.Field(i).PkgPath) > 0 && !strings.HasPrefix(tag, "type:") {
+			return nil, fmt.Errorf("%s field '%s' must be exported to be mapped", t, t.Field(i).Name)
+		}
+		f := mappedField{index: i, kind: strings.ToLower(strings.TrimSpace(parts[0]))}
+		if len(parts) == 2 {
+			f.name = strings.TrimSpace(parts[1])
+		}
+		switch f.kind {
+		case "type":
+			mapping.itemType = f.name
+		case "link":
+			if len(f.name) == 0 {
+				return nil, fmt.Errorf("%s field '%s' does not specify a link type", t, t.Field(i).Name)
+			}
+			mapping.links = append(mapping.links, f)
+		case "attr":
+			if len(f.name) == 0 {
+				f.name = strings.ToLower(t.Field(i).Name)
+			}
+			mapping.fields = append(mapping.fields, f)
+		case "key", "name", "description", "status", "partition", "txt", "version", "tag", "meta":
+			mapping.fields = append(mapping.fields, f)
+		default:
+			return nil, fmt.Errorf("%s field '%s' has an unknown oxc tag '%s'", t, t.Field(i).Name, tag)
+		}
+	}
+	if len(mapping.itemType) == 0 {
+		return nil, fmt.Errorf("%s does not define an item type, add a field like: _ struct{} `oxc:\"type:ITEM_TYPE\"`", t)
+	}
+	return mapping, nil
+}
+
+// gets the non nil struct values referenced by a link field (pointer or slice of pointers)
+func relatedValues(field reflect.Value) []reflect.Value {
+	var values []reflect.Value
+	switch field.Kind() {
+	case reflect.Ptr:
+		if !field.IsNil() {
+			values = append(values, field)
+		}
+	case reflect.Slice:
+		for i := 0; i < field.Len(); i++ {
+			if v := field.Index(i); v.Kind() != reflect.Ptr || !v.IsNil() {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// gets the JSON compatible representation of a field value
+func fieldValue(field reflect.Value) (interface{}, error) {
+	b, err := json.Marshal(field.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	return value, err
+}
+
+// sets a field from a JSON compatible value
+func setField(field reflect.Value, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, field.Addr().Interface())
+}
+
@@ -0,0 +1,170 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testRack struct {
+	_   struct{} `oxc:"type:RACK"`
+	Key string   `oxc:"key"`
+}
+
+type testHost struct {
+	_      struct{}  `oxc:"type:HOST"`
+	Key    string    `oxc:"key"`
+	Name   string    `oxc:"name"`
+	CPU    int       `oxc:"attr:cpu"`
+	OS     string    `oxc:"meta:os"`
+	Labels []string  `oxc:"tag"`
+	Rack   *testRack `oxc:"link:HOST_RACK"`
+}
+
+// how to map a struct to and from an item
+func TestMarshalItem(t *testing.T) {
+	host := &testHost{Key: "host_1", CPU: 4, OS: "linux", Labels: []string{"prod"}, Rack: &testRack{Key: "rack_1"}}
+	item, err := MarshalItem(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Type != "HOST" || item.Name != "host_1" || item.Meta["os"] != "linux" || len(item.Tag) != 1 {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+	loaded := new(testHost)
+	if err = UnmarshalItem(item, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CPU != 4 || loaded.OS != "linux" || loaded.Labels[0] != "prod" || loaded.Rack != nil {
+		t.Fatalf("unexpected struct: %+v", loaded)
+	}
+}
+
+type testServer struct {
+	_       struct{}    `oxc:"type:HOST"`
+	Key     string      `oxc:"key"`
+	Rack    *testRack   `oxc:"link:HOST_RACK"`
+	Backups []*testRack `oxc:"link:HOST_BACKUP"`
+}
+
+// checks that links of different types to the same item are saved and loaded separately
+func TestMapper_LinkTypes(t *testing.T) {
+	var mu sync.Mutex
+	items := make(map[string]Item)
+	links := make(map[string]Link)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case r.Method == http.MethodPut && parts[0] == "item":
+			item := Item{}
+			_ = json.NewDecoder(r.Body).Decode(&item)
+			items[item.Key] = item
+			_ = json.NewEncoder(w).Encode(Result{Operation: OpInsert, Changed: true, Ref: item.Key})
+		case r.Method == http.MethodPut && parts[0] == "link":
+			link := Link{}
+			_ = json.NewDecoder(r.Body).Decode(&link)
+			links[link.Key] = link
+			_ = json.NewEncoder(w).Encode(Result{Operation: OpInsert, Changed: true, Ref: link.Key})
+		case r.Method == http.MethodGet && parts[0] == "link":
+			list := LinkList{}
+			for _, link := range links {
+				if link.Type == r.URL.Query().Get("type") {
+					list.Values = append(list.Values, link)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodGet && len(parts) == 2:
+			_ = json.NewEncoder(w).Encode(items[parts[1]])
+		case r.Method == http.MethodGet && len(parts) == 4 && parts[2] == "list":
+			// the first level children of the item, whatever the link type
+			list := ItemList{}
+			children := make(map[string]bool)
+			for _, link := range links {
+				if link.StartItemKey == parts[1] && items[link.EndItemKey].Type == parts[3] && !children[link.EndItemKey] {
+					children[link.EndItemKey] = true
+					list.Values = append(list.Values, items[link.EndItemKey])
+				}
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMapper(c)
+	rack := &testRack{Key: "rack_1"}
+	err = m.Save(&testServer{Key: "host_1", Rack: rack, Backups: []*testRack{rack, {Key: "rack_2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links, got: %v", links)
+	}
+	loaded := new(testServer)
+	if err = m.Load("host_1", loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Rack == nil || loaded.Rack.Key != "rack_1" || len(loaded.Backups) != 2 {
+		t.Fatalf("unexpected struct: %+v", loaded)
+	}
+	host := &testServer{Key: "host_2", Backups: []*testRack{{Key: "rack_2"}}}
+	if err = m.Save(host); err != nil {
+		t.Fatal(err)
+	}
+	loaded = new(testServer)
+	if err = m.Load("host_2", loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Rack != nil || len(loaded.Backups) != 1 {
+		t.Fatalf("unexpected struct: %+v", loaded)
+	}
+}
+
+type testVersionedRack struct {
+	_       struct{} `oxc:"type:RACK"`
+	Key     string   `oxc:"key"`
+	Version int64    `oxc:"version"`
+}
+
+// checks that saving a struct with a stale version fails
+func TestMapper_SaveLocked(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items["rack_1"] = Item{Key: "rack_1", Name: "Rack 1", Type: "RACK", Version: 3}
+	err = NewMapper(c).Save(&testVersionedRack{Key: "rack_1", Version: 2})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected a version conflict, got: %v", err)
+	}
+	if items["rack_1"].Name != "Rack 1" {
+		t.Fatalf("item changed by a locked save: %+v", items["rack_1"])
+	}
+}
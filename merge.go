@@ -0,0 +1,191 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// the error returned by MergeFail for each conflict
+var ErrMergeConflict = errors.New("merge conflict")
+
+// a field changed differently in ours and theirs
+type MergeConflict struct {
+	// the JSON pointer of the field (e.g. /attribute/cpu)
+	Path string
+	// the value in the common ancestor, nil if the field was not present
+	Base interface{}
+	// our value, nil if we removed the field
+	Ours interface{}
+	// their value, nil if they removed the field
+	Theirs interface{}
+}
+
+// resolves a merge conflict, returning the merged value or nil to remove the field
+// returning an error wrapping ErrMergeConflict records the conflict as unresolved, any other error stops the merge
+type MergeStrategy func(conflict MergeConflict) (interface{}, error)
+
+var (
+	// resolves conflicts using our value
+	MergeOurs MergeStrategy = func(c MergeConflict) (interface{}, error) { return c.Ours, nil }
+	// resolves conflicts using their value
+	MergeTheirs MergeStrategy = func(c MergeConflict) (interface{}, error) { return c.Theirs, nil }
+	// does not resolve conflicts, the merge fails with a *MergeError listing them
+	MergeFail MergeStrategy = func(c MergeConflict) (interface{}, error) { return nil, ErrMergeConflict }
+)
+
+// the error returned when a merge has unresolved conflicts
+type MergeError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeError) Error() string {
+	paths := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		paths[i] = c.Path
+	}
+	return fmt.Sprintf("%d unresolved merge conflict(s): %s", len(e.Conflicts), strings.Join(paths, ", "))
+}
+
+func (e *MergeError) Unwrap() error {
+	return ErrMergeConflict
+}
+
+// merges the changes made to an item in ours and theirs since base
+// changes to different attribute and meta keys are combined, conflicting changes are resolved by the strategy
+// the fields managed by the Web API (e.g. version) are taken from theirs, which is usually the current server state
+func MergeItems(base, ours, theirs *Item, strategy MergeStrategy) (*Item, error) {
+	merged := new(Item)
+	if err := merge3(base, ours, theirs, merged, strategy); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// merges the changes made to a link in ours and theirs since base
+// changes to different attribute and meta keys are combined, conflicting changes are resolved by the strategy
+// the fields managed by the Web API (e.g. version) are taken from theirs, which is usually the current server state
+func MergeLinks(base, ours, theirs *Link, strategy MergeStrategy) (*Link, error) {
+	merged := new(Link)
+	if err := merge3(base, ours, theirs, merged, strategy); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// merges three versions of an entity into target via their JSON representation
+func merge3(base, ours, theirs, target interface{}, strategy MergeStrategy) error {
+	if strategy == nil {
+		strategy = MergeFail
+	}
+	var maps [3]map[string]interface{}
+	for i, entity := range []interface{}{base, ours, theirs} {
+		m, err := toFieldMap(entity)
+		if err != nil {
+			return err
+		}
+		maps[i] = m
+	}
+	m := &merger{strategy: strategy}
+	merged := make(map[string]interface{})
+	for field, value := range maps[2] {
+		if serverFields[field] {
+			merged[field] = value
+		}
+	}
+	for field := range serverFields {
+		delete(maps[0], field)
+		delete(maps[1], field)
+		delete(maps[2], field)
+	}
+	result, err := m.merge("", maps[0], maps[1], maps[2])
+	if err != nil {
+		return err
+	}
+	for field, value := range result.(map[string]interface{}) {
+		merged[field] = value
+	}
+	if len(m.conflicts) > 0 {
+		return &MergeError{Conflicts: m.conflicts}
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, target)
+}
+
+// holds the state of a three-way merge
+type merger struct {
+	strategy  MergeStrategy
+	conflicts []MergeConflict
+}
+
+// merges a JSON value, recursing into objects changed on both sides
+func (m *merger) merge(path string, base, ours, theirs interface{}) (interface{}, error) {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours, nil
+	case reflect.DeepEqual(base, ours):
+		// only they changed it
+		return theirs, nil
+	case reflect.DeepEqual(base, theirs):
+		// only we changed it
+		return ours, nil
+	}
+	b, bOk := base.(map[string]interface{})
+	o, oOk := ours.(map[string]interface{})
+	t, tOk := theirs.(map[string]interface{})
+	if oOk && tOk && o != nil && t != nil {
+		if !bOk {
+			b = map[string]interface{}{}
+		}
+		keys := make(map[string]bool)
+		for _, mp := range []map[string]interface{}{b, o, t} {
+			for key := range mp {
+				keys[key] = true
+			}
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		merged := make(map[string]interface{})
+		for _, key := range sorted {
+			value, err := m.merge(path+"/"+escapePointer(key), b[key], o[key], t[key])
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				merged[key] = value
+			}
+		}
+		return merged, nil
+	}
+	conflict := MergeConflict{Path: path, Base: base, Ours: ours, Theirs: theirs}
+	value, err := m.strategy(conflict)
+	if errors.Is(err, ErrMergeConflict) {
+		m.conflicts = append(m.conflicts, conflict)
+		return ours, nil
+	}
+	return value, err
+}
@@ -0,0 +1,54 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"testing"
+)
+
+// checks that changes to different keys are combined and conflicts are resolved by the strategy
+func TestMergeItems(t *testing.T) {
+	base := &Item{Key: "item_1", Name: "Item 1", Attribute: map[string]interface{}{"cpu": 2, "ram": 4, "disk": 10}, Version: 1}
+	ours := &Item{Key: "item_1", Name: "Item 1", Attribute: map[string]interface{}{"cpu": 4, "ram": 4}, Version: 1}
+	theirs := &Item{Key: "item_1", Name: "Item One", Attribute: map[string]interface{}{"cpu": 8, "ram": 4, "disk": 10, "gpu": 1}, Version: 2}
+	// cpu was changed by both
+	_, err := MergeItems(base, ours, theirs, MergeFail)
+	var mergeErr *MergeError
+	if !errors.As(err, &mergeErr) || !errors.Is(err, ErrMergeConflict) || len(mergeErr.Conflicts) != 1 || mergeErr.Conflicts[0].Path != "/attribute/cpu" {
+		t.Fatalf("expected a conflict on /attribute/cpu, got: %v", err)
+	}
+	merged, err := MergeItems(base, ours, theirs, MergeOurs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// takes their name and gpu, our cpu and our removal of disk, and their version
+	if merged.Name != "Item One" || merged.Attribute["cpu"] != float64(4) || merged.Attribute["gpu"] != float64(1) || merged.Version != 2 {
+		t.Fatalf("unexpected merge: %+v", merged)
+	}
+	if _, exists := merged.Attribute["disk"]; exists {
+		t.Fatalf("disk should have been removed: %+v", merged.Attribute)
+	}
+	merged, err = MergeItems(base, ours, theirs, func(c MergeConflict) (interface{}, error) {
+		return c.Ours.(float64) + c.Theirs.(float64), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Attribute["cpu"] != float64(12) {
+		t.Fatalf("unexpected custom merge: %+v", merged.Attribute)
+	}
+}
@@ -0,0 +1,269 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"fmt"
+	"github.com/xeipuuv/gojsonschema"
+	"strings"
+	"sync"
+)
+
+// a single problem found when validating the meta of an item or link against its type meta schema
+type MetaViolation struct {
+	// the kind of entity validated (i.e. item or link)
+	Entity string
+	// the key of the item or link
+	Key string
+	// the JSON pointer to the offending value within the meta (e.g. /disks/0/size)
+	Path string
+	// the reason for the violation
+	Reason string
+}
+
+func (v MetaViolation) String() string {
+	return fmt.Sprintf("%s '%s' meta '%s': %s", v.Entity, v.Key, v.Path, v.Reason)
+}
+
+// the error returned when the meta of one or more items or links does not comply with the type meta schema
+type MetaValidationError struct {
+	Violations []MetaViolation
+}
+
+func (e *MetaValidationError) Error() string {
+	msg := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msg[i] = v.String()
+	}
+	return fmt.Sprintf("%d meta violation(s) found: %s", len(e.Violations), strings.Join(msg, "; "))
+}
+
+// validates item and link meta against the JSON schema (draft-07 or earlier) defined by their types
+// schemas are retrieved from the Web API the first time a type is seen and then compiled and cached
+type MetaValidator struct {
+	client    *Client
+	lock      sync.Mutex
+	itemTypes map[string]*gojsonschema.Schema
+	linkTypes map[string]*gojsonschema.Schema
+}
+
+// creates a new meta validator using the passed-in client to retrieve type meta schemas
+// client: can be nil if only graph data defining its own types is to be validated
+func NewMetaValidator(client *Client) *MetaValidator {
+	return &MetaValidator{
+		client:    client,
+		itemTypes: make(map[string]*gojsonschema.Schema),
+		linkTypes: make(map[string]*gojsonschema.Schema),
+	}
+}
+
+// clears the cached schemas, so that they are reloaded next time they are needed
+func (v *MetaValidator) Reset() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.itemTypes = make(map[string]*gojsonschema.Schema)
+	v.linkTypes = make(map[string]*gojsonschema.Schema)
+}
+
+// validates the meta of the item against the meta schema of its item type
+func (v *MetaValidator) ValidateItem(item *Item) error {
+	schema, err := v.itemTypeSchema(item.Type)
+	if err != nil {
+		return err
+	}
+	violations, err := checkMeta("item", item.Key, item.Meta, schema)
+	if err != nil {
+		return err
+	}
+	return toMetaError(violations)
+}
+
+// validates the meta of the link against the meta schema of its link type
+func (v *MetaValidator) ValidateLink(link *Link) error {
+	schema, err := v.linkTypeSchema(link.Type)
+	if err != nil {
+		return err
+	}
+	violations, err := checkMeta("link", link.Key, link.Meta, schema)
+	if err != nil {
+		return err
+	}
+	return toMetaError(violations)
+}
+
+// validates the meta of all items and links in the graph data
+// types defined in the graph data are validated using the schemas in the graph data,
+// any other types are validated using the schemas held by the Web API or skipped if the validator has no client
+func (v *MetaValidator) ValidateData(data *GraphData) error {
+	itemSchemas := make(map[string]*gojsonschema.Schema)
+	for _, itemType := range data.ItemTypes {
+		schema, err := compileMetaSchema(itemType.MetaSchema)
+		if err != nil {
+			return fmt.Errorf("invalid meta schema for item type '%s': %s", itemType.Key, err)
+		}
+		itemSchemas[itemType.Key] = schema
+	}
+	linkSchemas := make(map[string]*gojsonschema.Schema)
+	for _, linkType := range data.LinkTypes {
+		schema, err := compileMetaSchema(linkType.MetaSchema)
+		if err != nil {
+			return fmt.Errorf("invalid meta schema for link type '%s': %s", linkType.Key, err)
+		}
+		linkSchemas[linkType.Key] = schema
+	}
+	var violations []MetaViolation
+	for _, item := range data.Items {
+		schema, ok := itemSchemas[item.Type]
+		if !ok && v.client != nil {
+			var err error
+			if schema, err = v.itemTypeSchema(item.Type); err != nil {
+				return err
+			}
+		}
+		found, err := checkMeta("item", item.Key, item.Meta, schema)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, found...)
+	}
+	for _, link := range data.Links {
+		schema, ok := linkSchemas[link.Type]
+		if !ok && v.client != nil {
+			var err error
+			if schema, err = v.linkTypeSchema(link.Type); err != nil {
+				return err
+			}
+		}
+		found, err := checkMeta("link", link.Key, link.Meta, schema)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, found...)
+	}
+	return toMetaError(violations)
+}
+
+// validates the meta of the items and links in the graph data using only the schemas defined in the graph data
+// items and links of types not defined in the graph data are not checked
+func (data *GraphData) ValidateMeta() error {
+	return NewMetaValidator(nil).ValidateData(data)
+}
+
+// validates offline the meta of the items and links in GraphData YAML or JSON files or directories
+func ValidateDataFile(paths ...string) error {
+	data, err := LoadData(paths...)
+	if err != nil {
+		return err
+	}
+	return data.ValidateMeta()
+}
+
+// gets the compiled meta schema for an item type, from the cache if possible
+func (v *MetaValidator) itemTypeSchema(itemTypeKey string) (*gojsonschema.Schema, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if schema, ok := v.itemTypes[itemTypeKey]; ok {
+		return schema, nil
+	}
+	itemType, err := v.client.GetItemType(&ItemType{Key: itemTypeKey})
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve meta schema for item type '%s': %s", itemTypeKey, err)
+	}
+	schema, err := compileMetaSchema(itemType.MetaSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid meta schema for item type '%s': %s", itemTypeKey, err)
+	}
+	v.itemTypes[itemTypeKey] = schema
+	return schema, nil
+}
+
+// gets the compiled meta schema for a link type, from the cache if possible
+func (v *MetaValidator) linkTypeSchema(linkTypeKey string) (*gojsonschema.Schema, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if schema, ok := v.linkTypes[linkTypeKey]; ok {
+		return schema, nil
+	}
+	linkType, err := v.client.GetLinkType(&LinkType{Key: linkTypeKey})
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve meta schema for link type '%s': %s", linkTypeKey, err)
+	}
+	schema, err := compileMetaSchema(linkType.MetaSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid meta schema for link type '%s': %s", linkTypeKey, err)
+	}
+	v.linkTypes[linkTypeKey] = schema
+	return schema, nil
+}
+
+// compiles a meta schema, returning nil if no schema is defined
+func compileMetaSchema(metaSchema map[string]interface{}) (*gojsonschema.Schema, error) {
+	if len(metaSchema) == 0 {
+		return nil, nil
+	}
+	loader := gojsonschema.NewSchemaLoader()
+	// uses draft-07 unless the schema declares a different version in $schema
+	loader.Draft = gojsonschema.Draft7
+	loader.AutoDetect = true
+	return loader.Compile(gojsonschema.NewGoLoader(metaSchema))
+}
+
+// checks the meta against the schema and returns all violations found
+func checkMeta(entity, key string, meta map[string]interface{}, schema *gojsonschema.Schema) ([]MetaViolation, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	// a missing meta is validated as an empty object
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	result, err := schema.Validate(gojsonschema.NewGoLoader(meta))
+	if err != nil {
+		return nil, fmt.Errorf("cannot validate meta for %s '%s': %s", entity, key, err)
+	}
+	var violations []MetaViolation
+	for _, e := range result.Errors() {
+		violations = append(violations, MetaViolation{
+			Entity: entity,
+			Key:    key,
+			Path:   metaPath(e),
+			Reason: e.Description(),
+		})
+	}
+	return violations, nil
+}
+
+// gets the JSON pointer for the value in error
+func metaPath(e gojsonschema.ResultError) string {
+	path := strings.TrimPrefix(e.Context().String("/"), gojsonschema.STRING_CONTEXT_ROOT)
+	// required properties are reported against their parent, so point at the missing property instead
+	if e.Type() == "required" {
+		if property, ok := e.Details()["property"]; ok {
+			path = fmt.Sprintf("%s/%v", path, property)
+		}
+	}
+	if len(path) == 0 {
+		return "/"
+	}
+	return path
+}
+
+func toMetaError(violations []MetaViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return &MetaValidationError{Violations: violations}
+}
@@ -0,0 +1,60 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import "testing"
+
+// validates item meta against a meta schema defined in the graph data
+func TestGraphData_ValidateMeta(t *testing.T) {
+	data := &GraphData{
+		ItemTypes: []ItemType{
+			{
+				Key:  "VM",
+				Name: "Virtual Machine",
+				MetaSchema: map[string]interface{}{
+					"$schema":  "http://json-schema.org/draft-07/schema#",
+					"type":     "object",
+					"required": []interface{}{"os"},
+					"properties": map[string]interface{}{
+						"os": map[string]interface{}{"type": "string"},
+						"disks": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]interface{}{"type": "integer"},
+						},
+					},
+				},
+			},
+		},
+		Items: []Item{
+			{Key: "vm_1", Name: "VM 1", Type: "VM", Meta: map[string]interface{}{"os": "linux", "disks": []interface{}{10, 20}}},
+			{Key: "vm_2", Name: "VM 2", Type: "VM", Meta: map[string]interface{}{"disks": []interface{}{10, "big"}}},
+		},
+	}
+	err := data.ValidateMeta()
+	if err == nil {
+		t.Fatal("expected meta validation errors")
+	}
+	paths := make(map[string]bool)
+	for _, v := range err.(*MetaValidationError).Violations {
+		if v.Key != "vm_2" {
+			t.Fatalf("unexpected violation: %s", v)
+		}
+		paths[v.Path] = true
+	}
+	if !paths["/os"] || !paths["/disks/1"] || len(paths) != 2 {
+		t.Fatalf("unexpected violation paths: %v", paths)
+	}
+}
@@ -84,3 +84,11 @@ func (model *Model) valid() error {
 	}
 	return nil
 }
+
+// Get the FQN for the model type data resource (i.e. the item types, link types, attributes and rules in the model)
+func (model *Model) uriData(baseUrl string) (string, error) {
+	if len(model.Key) == 0 {
+		return "", fmt.Errorf("the model does not have a key: cannot construct Model data resource URI")
+	}
+	return fmt.Sprintf("%s/model/%s/data", baseUrl, model.Key), nil
+}
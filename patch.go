@@ -0,0 +1,222 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// the error returned when an entity is changed by someone else while it is being updated
+// e.g. when an item keeps changing while a patch is being applied, or an update is rejected as locked
+var ErrVersionConflict = errors.New("version conflict")
+
+// the number of times a patch is retried when the item is changed by someone else in the meantime
+const patchRetries = 3
+
+// a partial update of an item
+// for example: NewItemPatch().SetAttr("cpu", 4).UnsetAttr("gpu").AddTag("prod")
+type ItemPatch struct {
+	setAttrs   map[string]interface{}
+	unsetAttrs []string
+	meta       map[string]interface{}
+	addTags    []interface{}
+	removeTags []interface{}
+	status     *int
+}
+
+// creates an empty item patch
+func NewItemPatch() *ItemPatch {
+	return &ItemPatch{}
+}
+
+// sets the value of an attribute
+func (p *ItemPatch) SetAttr(name string, value interface{}) *ItemPatch {
+	if p.setAttrs == nil {
+		p.setAttrs = make(map[string]interface{})
+	}
+	p.setAttrs[name] = value
+	// the last change to an attribute wins
+	p.unsetAttrs = removeValue(p.unsetAttrs, name)
+	return p
+}
+
+// removes an attribute
+func (p *ItemPatch) UnsetAttr(name string) *ItemPatch {
+	delete(p.setAttrs, name)
+	p.unsetAttrs = append(removeValue(p.unsetAttrs, name), name)
+	return p
+}
+
+// merges the meta as per RFC 7386: objects are merged recursively and null values remove properties
+func (p *ItemPatch) MergeMeta(meta map[string]interface{}) *ItemPatch {
+	if p.meta == nil {
+		p.meta = make(map[string]interface{})
+	}
+	composePatch(p.meta, meta)
+	return p
+}
+
+// adds a tag if the item does not have it
+func (p *ItemPatch) AddTag(tag interface{}) *ItemPatch {
+	// the last change to a tag wins
+	p.removeTags = removeTag(p.removeTags, tag)
+	p.addTags = append(p.addTags, tag)
+	return p
+}
+
+// removes a tag if the item has it
+func (p *ItemPatch) RemoveTag(tag interface{}) *ItemPatch {
+	p.addTags = removeTag(p.addTags, tag)
+	p.removeTags = append(p.removeTags, tag)
+	return p
+}
+
+// sets the item status
+func (p *ItemPatch) SetStatus(status int) *ItemPatch {
+	p.status = &status
+	return p
+}
+
+// true if the patch does not change anything
+func (p *ItemPatch) IsEmpty() bool {
+	return len(p.setAttrs) == 0 && len(p.unsetAttrs) == 0 && len(p.meta) == 0 &&
+		len(p.addTags) == 0 && len(p.removeTags) == 0 && p.status == nil
+}
+
+// applies the patch to an item
+func (p *ItemPatch) Apply(item *Item) {
+	if len(p.setAttrs) > 0 && item.Attribute == nil {
+		item.Attribute = make(map[string]interface{})
+	}
+	for name, value := range p.setAttrs {
+		item.Attribute[name] = value
+	}
+	for _, name := range p.unsetAttrs {
+		delete(item.Attribute, name)
+	}
+	if len(p.meta) > 0 {
+		if item.Meta == nil {
+			item.Meta = make(map[string]interface{})
+		}
+		mergePatch(item.Meta, p.meta)
+	}
+	for _, tag := range p.addTags {
+		if tagIndex(item.Tag, tag) < 0 {
+			item.Tag = append(item.Tag, tag)
+		}
+	}
+	for _, tag := range p.removeTags {
+		item.Tag = removeTag(item.Tag, tag)
+	}
+	if p.status != nil {
+		item.Status = *p.status
+	}
+}
+
+// the RFC 7386 merge patch document equivalent to the patch
+// returns false if the patch cannot be expressed as a merge patch, as tag changes require the current tags
+func (p *ItemPatch) mergePatchDoc() (map[string]interface{}, bool) {
+	if len(p.addTags) > 0 || len(p.removeTags) > 0 {
+		return nil, false
+	}
+	doc := make(map[string]interface{})
+	if len(p.setAttrs) > 0 || len(p.unsetAttrs) > 0 {
+		attrs := make(map[string]interface{})
+		for name, value := range p.setAttrs {
+			attrs[name] = value
+		}
+		for _, name := range p.unsetAttrs {
+			attrs[name] = nil
+		}
+		doc["attribute"] = attrs
+	}
+	if len(p.meta) > 0 {
+		doc["meta"] = p.meta
+	}
+	if p.status != nil {
+		doc["status"] = *p.status
+	}
+	return doc, true
+}
+
+// merges a patch into a target map as per RFC 7386
+func mergePatch(target, patch map[string]interface{}) {
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if patchMap, ok := value.(map[string]interface{}); ok {
+			targetMap, ok := target[key].(map[string]interface{})
+			if !ok {
+				targetMap = make(map[string]interface{})
+			}
+			mergePatch(targetMap, patchMap)
+			target[key] = targetMap
+			continue
+		}
+		target[key] = value
+	}
+}
+
+// combines two merge patches into target, keeping the null values that remove properties
+func composePatch(target, patch map[string]interface{}) {
+	for key, value := range patch {
+		patchMap, ok := value.(map[string]interface{})
+		targetMap, isMap := target[key].(map[string]interface{})
+		if ok && isMap {
+			composePatch(targetMap, patchMap)
+			continue
+		}
+		if ok {
+			// copies the patch so that later changes to it do not alter the target
+			targetMap = make(map[string]interface{})
+			composePatch(targetMap, patchMap)
+			value = targetMap
+		}
+		target[key] = value
+	}
+}
+
+// removes all occurrences of a tag from a list of tags
+func removeTag(tags []interface{}, tag interface{}) []interface{} {
+	for i := tagIndex(tags, tag); i >= 0; i = tagIndex(tags, tag) {
+		tags = append(tags[:i], tags[i+1:]...)
+	}
+	return tags
+}
+
+// removes all occurrences of a value from a list of strings
+func removeValue(values []string, value string) []string {
+	result := values[:0]
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// the index of a tag in a list of tags or -1 if it is not in the list
+func tagIndex(tags []interface{}, tag interface{}) int {
+	for i, t := range tags {
+		if fmt.Sprint(t) == fmt.Sprint(tag) {
+			return i
+		}
+	}
+	return -1
+}
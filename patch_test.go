@@ -0,0 +1,123 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// checks that a patch changes the requested fields only
+func TestItemPatch_Apply(t *testing.T) {
+	item := &Item{
+		Key:       "item_1",
+		Attribute: map[string]interface{}{"cpu": 2, "ram": 4},
+		Meta:      map[string]interface{}{"owner": map[string]interface{}{"name": "ops", "email": "ops@example.com"}},
+		Tag:       []interface{}{"dev", "linux"},
+	}
+	NewItemPatch().
+		SetAttr("cpu", 4).
+		UnsetAttr("ram").
+		MergeMeta(map[string]interface{}{"owner": map[string]interface{}{"email": nil}, "site": "london"}).
+		AddTag("prod").
+		AddTag("linux").
+		RemoveTag("dev").
+		SetStatus(2).
+		Apply(item)
+	if !reflect.DeepEqual(item.Attribute, map[string]interface{}{"cpu": 4}) {
+		t.Fatalf("unexpected attributes: %v", item.Attribute)
+	}
+	if !reflect.DeepEqual(item.Meta, map[string]interface{}{"owner": map[string]interface{}{"name": "ops"}, "site": "london"}) {
+		t.Fatalf("unexpected meta: %v", item.Meta)
+	}
+	if !reflect.DeepEqual(item.Tag, []interface{}{"linux", "prod"}) || item.Status != 2 {
+		t.Fatalf("unexpected tags or status: %v %d", item.Tag, item.Status)
+	}
+}
+
+// checks that the last change to an attribute or tag wins
+func TestItemPatch_LastChangeWins(t *testing.T) {
+	patch := NewItemPatch().
+		SetAttr("a", 1).UnsetAttr("a").SetAttr("a", 2).
+		SetAttr("b", 1).UnsetAttr("b").
+		AddTag("x").RemoveTag("x").AddTag("x").
+		RemoveTag("y").AddTag("y").RemoveTag("y")
+	item := &Item{Key: "item_1", Attribute: map[string]interface{}{"b": 0}, Tag: []interface{}{"y"}}
+	patch.Apply(item)
+	if !reflect.DeepEqual(item.Attribute, map[string]interface{}{"a": 2}) {
+		t.Fatalf("unexpected attributes: %v", item.Attribute)
+	}
+	if !reflect.DeepEqual(item.Tag, []interface{}{"x"}) {
+		t.Fatalf("unexpected tags: %v", item.Tag)
+	}
+	doc, ok := NewItemPatch().SetAttr("a", 1).UnsetAttr("a").SetAttr("a", 2).UnsetAttr("b").mergePatchDoc()
+	if !ok || !reflect.DeepEqual(doc["attribute"], map[string]interface{}{"a": 2, "b": nil}) {
+		t.Fatalf("unexpected merge patch: %v", doc)
+	}
+}
+
+// checks that a patch falls back to read-modify-write if the Web API does not support PATCH requests
+func TestClient_PatchItem(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items["item_1"] = Item{Key: "item_1", Name: "Item 1", Attribute: map[string]interface{}{"cpu": 2}, Version: 5}
+	result, err := c.PatchItem("item_1", NewItemPatch().SetAttr("cpu", 4))
+	checkResult(result, err, "cannot patch item", t)
+	if items["item_1"].Attribute["cpu"] != float64(4) || items["item_1"].Name != "Item 1" {
+		t.Fatalf("unexpected item: %+v", items["item_1"])
+	}
+	if c.noPatch != 1 {
+		t.Fatal("expected the client to stop sending PATCH requests")
+	}
+}
+
+// checks that patches are validated when the client validates attributes
+func TestClient_PatchItem_Validated(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	// the Web API accepts PATCH requests without validating them
+	patches := 0
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			patches++
+			_ = json.NewEncoder(w).Encode(Result{Operation: OpUpdate, Changed: true})
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.validator = NewAttrValidator(nil)
+	c.validator.itemTypes["HOST"] = []attrDef{{Name: "cpu", Type: "integer"}}
+	items["item_1"] = Item{Key: "item_1", Name: "Item 1", Type: "HOST", Attribute: map[string]interface{}{"cpu": 2}, Version: 5}
+	if _, err = c.PatchItem("item_1", NewItemPatch().SetAttr("cpu", "four")); err == nil {
+		t.Fatal("expected the invalid attribute to be rejected")
+	}
+	result, err := c.PatchItem("item_1", NewItemPatch().SetAttr("cpu", 4))
+	checkResult(result, err, "cannot patch item", t)
+	if patches > 0 || items["item_1"].Attribute["cpu"] != float64(4) {
+		t.Fatalf("expected the item to be validated and written back, got %d patches and %+v", patches, items["item_1"])
+	}
+}
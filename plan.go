@@ -0,0 +1,228 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// the action a plan step performs on an entity
+type PlanAction string
+
+const (
+	ActionCreate PlanAction = "create"
+	ActionUpdate PlanAction = "update"
+	ActionDelete PlanAction = "delete"
+	ActionNone   PlanAction = "none"
+)
+
+// the kinds of entity in GraphData, in the order they have to be created
+const (
+	KindModel             = "model"
+	KindItemType          = "itemType"
+	KindItemTypeAttribute = "itemTypeAttribute"
+	KindLinkType          = "linkType"
+	KindLinkTypeAttribute = "linkTypeAttribute"
+	KindLinkRule          = "linkRule"
+	KindItem              = "item"
+	KindLink              = "link"
+)
+
+// the entity kinds in dependency order, deletions are done in the reverse order
+var kindOrder = []string{
+	KindModel,
+	KindItemType,
+	KindItemTypeAttribute,
+	KindLinkType,
+	KindLinkTypeAttribute,
+	KindLinkRule,
+	KindItem,
+	KindLink,
+}
+
+// the fields managed by the Web API, not taken into account when comparing entities
+var serverFields = map[string]bool{
+	"version":   true,
+	"created":   true,
+	"updated":   true,
+	"changedBy": true,
+	"encKeyIx":  true,
+}
+
+// a change to a single entity
+type PlanStep struct {
+	// the kind of entity (e.g. item, linkType)
+	Kind string `json:"kind"`
+	// the entity key
+	Key string `json:"key"`
+	// what is to be done with the entity
+	Action PlanAction `json:"action"`
+	// the names of the fields to be updated
+	Changes []string `json:"changes,omitempty"`
+	// the desired entity for create and update actions, or the current entity for delete actions
+	entity interface{}
+}
+
+// the changes required to take the Web API from its current state to a desired state
+type Plan struct {
+	Steps []PlanStep `json:"steps"`
+}
+
+// options to control how a plan is worked out
+type PlanOptions struct {
+	// if true, entities in scope that are not in the desired state are deleted
+	Prune bool
+	// the keys of the models in scope for pruning, defaults to the models in the desired state
+	Models []string
+	// if set, only entities in the specified partition are pruned
+	Partition string
+	// if true, steps with no changes are included in the plan
+	IncludeNoOp bool
+}
+
+// true if the plan has any create, update or delete steps
+func (p *Plan) HasChanges() bool {
+	for _, step := range p.Steps {
+		if step.Action != ActionNone {
+			return true
+		}
+	}
+	return false
+}
+
+// the number of steps for each action
+func (p *Plan) Summary() map[PlanAction]int {
+	summary := map[PlanAction]int{ActionCreate: 0, ActionUpdate: 0, ActionDelete: 0, ActionNone: 0}
+	for _, step := range p.Steps {
+		summary[step.Action]++
+	}
+	return summary
+}
+
+// the machine readable plan
+func (p *Plan) Json() ([]byte, error) {
+	return ToJson(p)
+}
+
+// the human readable plan
+func (p *Plan) String() string {
+	var b strings.Builder
+	symbols := map[PlanAction]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-", ActionNone: " "}
+	for _, step := range p.Steps {
+		b.WriteString(fmt.Sprintf("%s %s %s '%s'", symbols[step.Action], step.Action, step.Kind, step.Key))
+		if len(step.Changes) > 0 {
+			b.WriteString(fmt.Sprintf(" (%s)", strings.Join(step.Changes, ", ")))
+		}
+		b.WriteString("\n")
+	}
+	s := p.Summary()
+	b.WriteString(fmt.Sprintf("plan: %d to create, %d to update, %d to delete\n", s[ActionCreate], s[ActionUpdate], s[ActionDelete]))
+	return b.String()
+}
+
+// the entities of each kind in the graph data
+func (data *GraphData) entities() map[string][]interface{} {
+	e := make(map[string][]interface{})
+	for i := range data.Models {
+		e[KindModel] = append(e[KindModel], &data.Models[i])
+	}
+	for i := range data.ItemTypes {
+		e[KindItemType] = append(e[KindItemType], &data.ItemTypes[i])
+	}
+	for i := range data.ItemTypeAttributes {
+		e[KindItemTypeAttribute] = append(e[KindItemTypeAttribute], &data.ItemTypeAttributes[i])
+	}
+	for i := range data.LinkTypes {
+		e[KindLinkType] = append(e[KindLinkType], &data.LinkTypes[i])
+	}
+	for i := range data.LinkTypeAttribute {
+		e[KindLinkTypeAttribute] = append(e[KindLinkTypeAttribute], &data.LinkTypeAttribute[i])
+	}
+	for i := range data.LinkRules {
+		e[KindLinkRule] = append(e[KindLinkRule], &data.LinkRules[i])
+	}
+	for i := range data.Items {
+		e[KindItem] = append(e[KindItem], &data.Items[i])
+	}
+	for i := range data.Links {
+		e[KindLink] = append(e[KindLink], &data.Links[i])
+	}
+	return e
+}
+
+// gets the value of the Key field of an entity
+func entityKey(entity interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("Key"); f.IsValid() {
+		return f.String()
+	}
+	return ""
+}
+
+// gets the names of the fields of the desired entity that have a different value in the current entity
+// fields managed by the Web API are not compared, null and empty lists or objects are considered equal
+func changedFields(desired, current interface{}) ([]string, error) {
+	d, err := toFieldMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	c, err := toFieldMap(current)
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	for name, value := range d {
+		if serverFields[name] {
+			continue
+		}
+		if !reflect.DeepEqual(value, c[name]) && !(isEmptyCollection(value) && isEmptyCollection(c[name])) {
+			changes = append(changes, name)
+		}
+	}
+	sort.Strings(changes)
+	return changes, nil
+}
+
+// converts an entity into a map of its JSON fields
+func toFieldMap(entity interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// true if a JSON value is null or an empty list or object
+func isEmptyCollection(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// checks that changes towards false, zero and empty values are detected
+func TestChangedFields(t *testing.T) {
+	cases := []struct {
+		desired interface{}
+		current interface{}
+		changes []string
+	}{
+		{&ItemTypeAttribute{Key: "a", Required: false}, &ItemTypeAttribute{Key: "a", Required: true}, []string{"required"}},
+		{&Item{Key: "i", Status: 0}, &Item{Key: "i", Status: 1}, []string{"status"}},
+		{&Item{Key: "i", Attribute: map[string]interface{}{}}, &Item{Key: "i", Attribute: map[string]interface{}{"a": 1}}, []string{"attribute"}},
+		{&ItemType{Key: "t", EncryptMeta: false}, &ItemType{Key: "t", EncryptMeta: true}, []string{"encryptMeta"}},
+		{&Item{Key: "i", Name: ""}, &Item{Key: "i", Name: "Name"}, []string{"name"}},
+		// null and empty collections are the same
+		{&Item{Key: "i", Attribute: map[string]interface{}{}, Tag: []interface{}{}}, &Item{Key: "i"}, nil},
+		// fields managed by the Web API are not compared
+		{&Item{Key: "i", Version: 1}, &Item{Key: "i", Version: 2, ChangedBy: "admin"}, nil},
+	}
+	for i, c := range cases {
+		changes, err := changedFields(c.desired, c.current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(changes, c.changes) {
+			t.Errorf("case %d: expected changes %v, got %v", i, c.changes, changes)
+		}
+	}
+}
+
+// checks the summary and output formats of a plan
+func TestPlan_Output(t *testing.T) {
+	plan := &Plan{Steps: []PlanStep{
+		{Kind: KindItem, Key: "a", Action: ActionCreate},
+		{Kind: KindItem, Key: "b", Action: ActionUpdate, Changes: []string{"name", "status"}},
+		{Kind: KindLink, Key: "c", Action: ActionDelete},
+	}}
+	summary := plan.Summary()
+	if summary[ActionCreate] != 1 || summary[ActionUpdate] != 1 || summary[ActionDelete] != 1 || summary[ActionNone] != 0 {
+		t.Fatalf("unexpected summary: %v", summary)
+	}
+	if !plan.HasChanges() {
+		t.Fatal("expected the plan to have changes")
+	}
+	expected := "+ create item 'a'\n" +
+		"~ update item 'b' (name, status)\n" +
+		"- delete link 'c'\n" +
+		"plan: 1 to create, 1 to update, 1 to delete\n"
+	if s := plan.String(); s != expected {
+		t.Fatalf("unexpected plan:\n%s", s)
+	}
+	b, err := plan.Json()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Plan)
+	if err = json.Unmarshal(b, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, plan) {
+		t.Fatalf("unexpected json: %s", b)
+	}
+}
+
+// checks that a plan creates, updates and skips items and that applying it converges
+func TestClient_PlanApply(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items["same"] = Item{Key: "same", Name: "Same", Version: 1}
+	items["changed"] = Item{Key: "changed", Name: "Changed", Status: 1, Version: 1}
+	desired := &GraphData{Items: []Item{
+		{Key: "same", Name: "Same"},
+		{Key: "changed", Name: "Changed", Status: 0},
+		{Key: "new", Name: "New"},
+	}}
+	plan, err := c.Plan(desired, &PlanOptions{IncludeNoOp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make(map[string]PlanAction)
+	for _, step := range plan.Steps {
+		actions[step.Key] = step.Action
+	}
+	if actions["same"] != ActionNone || actions["changed"] != ActionUpdate || actions["new"] != ActionCreate {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	if err = c.Apply(plan); err != nil {
+		t.Fatal(err)
+	}
+	if items["changed"].Status != 0 || items["new"].Name != "New" {
+		t.Fatalf("plan not applied: %+v", items)
+	}
+	plan, err = c.Plan(desired, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.HasChanges() {
+		t.Fatalf("expected no changes after apply, got:\n%s", plan)
+	}
+	// an update based on a stale version is rejected as locked and fails the step
+	items["same"] = Item{Key: "same", Name: "Same", Version: 2}
+	stale := &GraphData{Items: []Item{{Key: "same", Name: "Stale", Version: 1}}}
+	if plan, err = c.Plan(stale, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Apply(plan); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected a version conflict, got: %v", err)
+	}
+	if items["same"].Name != "Same" {
+		t.Fatalf("item 'same' changed by a locked update: %+v", items["same"])
+	}
+}
+
+// checks that pruned entities are deleted in reverse dependency order
+func TestClient_PlanPrune(t *testing.T) {
+	model := Model{Key: "m", Name: "Model"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/model/m":
+			_ = json.NewEncoder(w).Encode(model)
+		case r.URL.Path == "/model/m/data":
+			_ = json.NewEncoder(w).Encode(GraphData{
+				ItemTypes: []ItemType{{Key: "old-type", Model: "m"}},
+				LinkTypes: []LinkType{{Key: "old-link-type", Model: "m"}},
+			})
+		case r.URL.Path == "/item" && r.URL.Query().Get("type") == "old-type":
+			_ = json.NewEncoder(w).Encode(ItemList{Values: []Item{{Key: "old-item", Type: "old-type"}}})
+		case r.URL.Path == "/link" && r.URL.Query().Get("type") == "old-link-type":
+			_ = json.NewEncoder(w).Encode(LinkList{Values: []Link{{Key: "old-link", Type: "old-link-type"}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := c.Plan(&GraphData{Models: []Model{model}}, &PlanOptions{Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []string
+	for _, step := range plan.Steps {
+		if step.Action != ActionDelete {
+			t.Fatalf("unexpected step: %+v", step)
+		}
+		steps = append(steps, step.Kind+":"+step.Key)
+	}
+	expected := []string{"link:old-link", "item:old-item", "linkType:old-link-type", "itemType:old-type"}
+	if strings.Join(steps, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected deletions %v, got %v", expected, steps)
+	}
+}
+
+// checks that pruning a partition deletes the items in the partition and the links to them
+func TestClient_PlanPrunePartition(t *testing.T) {
+	server := fakeModelServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// keeps the meta-model, so that only the items and links are pruned
+	desired := &GraphData{
+		Models:    []Model{{Key: "m", Name: "Model", Partition: "p1"}},
+		ItemTypes: []ItemType{{Key: "A", Model: "m"}, {Key: "B", Model: "m"}},
+		LinkTypes: []LinkType{{Key: "L", Model: "m"}},
+		LinkRules: []LinkRule{{Key: "A->B", LinkTypeKey: "L", StartItemTypeKey: "A", EndItemTypeKey: "B"}},
+	}
+	plan, err := c.Plan(desired, &PlanOptions{Prune: true, Partition: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deleted []string
+	for _, step := range plan.Steps {
+		if step.Action == ActionDelete {
+			deleted = append(deleted, step.Kind+":"+step.Key)
+		}
+	}
+	// the links of the model types are pruned whatever the partition of their ends
+	expected := "[link:a1->b1 link:a2->b1 item:a1 item:b1]"
+	if fmt.Sprint(deleted) != expected {
+		t.Fatalf("expected deletions %s, got %v", expected, deleted)
+	}
+}
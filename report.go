@@ -0,0 +1,171 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// the operations reported by the Web API in Result.Operation
+const (
+	OpInsert = "I"
+	OpUpdate = "U"
+	OpNone   = "N"
+	OpLocked = "L"
+	OpDelete = "D"
+	// the operation is not known, e.g. when the Web API does not report results for each entity
+	OpUnknown = "?"
+)
+
+// the result of writing a single entity
+type EntityResult struct {
+	// the kind of entity (e.g. item, linkType)
+	// empty if the Web API reported the result by a key shared by entities of different kinds
+	Kind string `json:"kind"`
+	// the entity key
+	Key string `json:"key"`
+	// the operation performed (see Op* constants)
+	Operation string `json:"operation"`
+	// true if the entity was changed
+	Changed bool `json:"changed"`
+	// the error message if the entity could not be written
+	Error string `json:"error,omitempty"`
+}
+
+// true if the entity could not be written
+func (r EntityResult) Failed() bool {
+	return len(r.Error) > 0 || r.Operation == OpLocked
+}
+
+// a detailed report of a bulk upload
+type DataReport struct {
+	Results []EntityResult `json:"results"`
+	// the number of entities inserted
+	Inserted int `json:"inserted"`
+	// the number of entities updated
+	Updated int `json:"updated"`
+	// the number of entities left unchanged
+	Unchanged int `json:"unchanged"`
+	// the number of entities that could not be written
+	Failed int `json:"failed"`
+	// the number of entities for which the operation is not known
+	Unknown int `json:"unknown"`
+	// true if the Web API did not report per entity results and the entities were sent individually
+	Individual bool `json:"individual"`
+}
+
+// adds an entity result to the report and updates the counters
+func (r *DataReport) add(result EntityResult) {
+	switch {
+	case result.Failed():
+		r.Failed++
+	case result.Operation == OpInsert:
+		r.Inserted++
+	case result.Operation == OpUpdate:
+		r.Updated++
+	case result.Operation == OpNone:
+		r.Unchanged++
+	default:
+		r.Unknown++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// the results of the entities that could not be written
+func (r *DataReport) Failures() []EntityResult {
+	var failures []EntityResult
+	for _, result := range r.Results {
+		if result.Failed() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// an error summarising the failures in the report or nil if there are no failures
+func (r *DataReport) Err() error {
+	failures := r.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+	msg := make([]string, len(failures))
+	for i, f := range failures {
+		reason := f.Error
+		if len(reason) == 0 {
+			reason = "locked"
+		}
+		kind := f.Kind
+		if len(kind) == 0 {
+			kind = "entity"
+		}
+		msg[i] = fmt.Sprintf("%s '%s': %s", kind, f.Key, reason)
+	}
+	return fmt.Errorf("%d of %d entities failed: %s", len(failures), len(r.Results), strings.Join(msg, "; "))
+}
+
+// the result of a PUT /data request, which may include the results for each entity
+type dataResult struct {
+	Result
+	Results []Result `json:"results"`
+}
+
+// decodes a PUT /data response
+func decodeDataResult(response *http.Response) (*dataResult, error) {
+	result := new(dataResult)
+	err := json.NewDecoder(response.Body).Decode(result)
+	defer func() {
+		if ferr := response.Body.Close(); ferr != nil {
+			err = ferr
+		}
+	}()
+	return result, err
+}
+
+// converts a Web API result into an entity result
+func newEntityResult(kind, key string, result *Result, err error) EntityResult {
+	r := EntityResult{Kind: kind, Key: key}
+	if result != nil {
+		r.Operation = result.Operation
+		r.Changed = result.Changed
+		if result.Error {
+			r.Error = result.Message
+		}
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// maps the entity keys to the kind of the entities, as the Web API reports per entity results by key only
+// keys shared by entities of different kinds are mapped to an empty kind, as the entity they refer to is not known
+func entityKinds(entities map[string][]interface{}) map[string]string {
+	kinds := make(map[string]string)
+	for _, kind := range kindOrder {
+		for _, entity := range entities[kind] {
+			key := entityKey(entity)
+			if current, exists := kinds[key]; exists && current != kind {
+				kinds[key] = ""
+			} else if !exists {
+				kinds[key] = kind
+			}
+		}
+	}
+	return kinds
+}
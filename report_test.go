@@ -0,0 +1,80 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// checks the report counters and the summary of failures
+func TestDataReport_Add(t *testing.T) {
+	report := new(DataReport)
+	report.add(newEntityResult(KindItem, "item_1", &Result{Operation: OpInsert, Changed: true}, nil))
+	report.add(newEntityResult(KindItem, "item_2", &Result{Operation: OpUpdate, Changed: true}, nil))
+	report.add(newEntityResult(KindItem, "item_3", &Result{Operation: OpNone}, nil))
+	report.add(newEntityResult(KindItem, "item_4", &Result{Operation: OpLocked}, nil))
+	report.add(newEntityResult(KindLink, "link_1", &Result{Error: true, Message: "item_9 not found"}, nil))
+	if report.Inserted != 1 || report.Updated != 1 || report.Unchanged != 1 || report.Failed != 2 {
+		t.Fatalf("unexpected counters: %+v", report)
+	}
+	failures := report.Failures()
+	if len(failures) != 2 || failures[0].Key != "item_4" || failures[1].Error != "item_9 not found" {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+	if report.Err() == nil {
+		t.Fatal("expected an error summarising the failures")
+	}
+}
+
+// checks that per entity results are not attributed to the wrong kind when a key is used by several kinds
+func TestClient_PutDataReport_SharedKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(dataResult{Results: []Result{
+			{Ref: "HOST", Operation: OpInsert, Changed: true},
+			{Ref: "HOST", Operation: OpNone},
+			{Ref: "host_1", Operation: OpInsert, Changed: true},
+		}})
+	}))
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &GraphData{
+		ItemTypes: []ItemType{{Key: "HOST"}},
+		LinkTypes: []LinkType{{Key: "HOST"}},
+		Items:     []Item{{Key: "host_1", Type: "HOST"}},
+	}
+	report, err := c.PutDataReport(data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 3 || report.Inserted != 2 || report.Unchanged != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for _, result := range report.Results {
+		expected := KindItem
+		if result.Key == "HOST" {
+			expected = ""
+		}
+		if result.Kind != expected {
+			t.Fatalf("expected kind '%s' for key '%s', got '%s'", expected, result.Key, result.Kind)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// a set of tags, kept in the order they were added
+type Tags []string
+
+// converts the tags of an item or link into a tag set, duplicates are removed
+func TagsOf(tags []interface{}) Tags {
+	var t Tags
+	for _, tag := range tags {
+		t = t.Add(fmt.Sprint(tag))
+	}
+	return t
+}
+
+// true if the set has the tag
+func (t Tags) Has(tag string) bool {
+	for _, v := range t {
+		if v == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// true if the set has all the tags
+func (t Tags) HasAll(tags ...string) bool {
+	for _, tag := range tags {
+		if !t.Has(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// true if the set has any of the tags
+func (t Tags) HasAny(tags ...string) bool {
+	for _, tag := range tags {
+		if t.Has(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// gets a set with the tags added, tags already in the set are not added again
+func (t Tags) Add(tags ...string) Tags {
+	result := append(Tags{}, t...)
+	for _, tag := range tags {
+		if !result.Has(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// gets a set without the tags
+func (t Tags) Remove(tags ...string) Tags {
+	remove := Tags(tags)
+	result := Tags{}
+	for _, tag := range t {
+		if !remove.Has(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// gets a set with a tag replaced by another, the new tag takes the position of the old one
+func (t Tags) Replace(old, new string) Tags {
+	if !t.Has(old) {
+		return t.Add(new)
+	}
+	result := Tags{}
+	for _, tag := range t {
+		if tag == old {
+			tag = new
+		}
+		if !result.Has(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// gets a set with the tags trimmed and in lower case, without empty tags or duplicates
+func (t Tags) Normalise() Tags {
+	result := Tags{}
+	for _, tag := range t {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) > 0 && !result.Has(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// gets a sorted copy of the set
+func (t Tags) Sorted() Tags {
+	result := append(Tags{}, t...)
+	sort.Strings(result)
+	return result
+}
+
+// converts the set into the representation used by items and links
+func (t Tags) Values() []interface{} {
+	if len(t) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(t))
+	for i, tag := range t {
+		values[i] = tag
+	}
+	return values
+}
+
+// the item tags
+func (item *Item) Tags() Tags {
+	return TagsOf(item.Tag)
+}
+
+// sets the item tags
+func (item *Item) SetTags(tags Tags) {
+	item.Tag = tags.Values()
+}
+
+// true if the item has the tag
+func (item *Item) HasTag(tag string) bool {
+	return item.Tags().Has(tag)
+}
+
+// adds tags to the item
+func (item *Item) AddTags(tags ...string) {
+	item.SetTags(item.Tags().Add(tags...))
+}
+
+// removes tags from the item
+func (item *Item) RemoveTags(tags ...string) {
+	item.SetTags(item.Tags().Remove(tags...))
+}
+
+// the link tags
+func (link *Link) Tags() Tags {
+	return TagsOf(link.Tag)
+}
+
+// sets the link tags
+func (link *Link) SetTags(tags Tags) {
+	link.Tag = tags.Values()
+}
+
+// true if the link has the tag
+func (link *Link) HasTag(tag string) bool {
+	return link.Tags().Has(tag)
+}
+
+// adds tags to the link
+func (link *Link) AddTags(tags ...string) {
+	link.SetTags(link.Tags().Add(tags...))
+}
+
+// removes tags from the link
+func (link *Link) RemoveTags(tags ...string) {
+	link.SetTags(link.Tags().Remove(tags...))
+}
+
+// the criteria to select items
+type ItemQuery struct {
+	// if set, only items of this type are selected
+	Type string
+	// if set, only items having all these tags are selected
+	Tags []string
+	// if set, only items created within the range are selected
+	Created TimeRange
+	// if set, only items last changed within the range are selected
+	Updated TimeRange
+	// if set, only items for which the function returns true are selected
+	Filter func(item *Item) bool
+}
+
+// the URI of the items matching the query, the Web API may ignore some of the criteria so results are filtered again
+func (q *ItemQuery) uri(baseUrl string) string {
+	params := url.Values{}
+	if len(q.Type) > 0 {
+		params.Set("type", q.Type)
+	}
+	if len(q.Tags) > 0 {
+		params.Set("tag", strings.Join(q.Tags, "|"))
+	}
+	if len(params) == 0 {
+		return fmt.Sprintf("%s/item", baseUrl)
+	}
+	return fmt.Sprintf("%s/item?%s", baseUrl, params.Encode())
+}
+
+// true if the item matches the query
+func (q *ItemQuery) matches(item *Item) (bool, error) {
+	if len(q.Type) > 0 && item.Type != q.Type {
+		return false, nil
+	}
+	if !item.Tags().HasAll(q.Tags...) {
+		return false, nil
+	}
+	if !q.Created.IsZero() {
+		created, err := item.CreatedTime()
+		if err != nil {
+			return false, err
+		}
+		if !q.Created.Contains(created) {
+			return false, nil
+		}
+	}
+	if !q.Updated.IsZero() {
+		updated, err := changedTime(item.Created, item.Updated)
+		if err != nil {
+			return false, err
+		}
+		if !q.Updated.Contains(updated) {
+			return false, nil
+		}
+	}
+	return q.Filter == nil || q.Filter(item), nil
+}
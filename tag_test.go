@@ -0,0 +1,63 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"reflect"
+	"testing"
+)
+
+// checks the tag set operations
+func TestTags(t *testing.T) {
+	tags := TagsOf([]interface{}{"linux", "dev", "linux"})
+	if !reflect.DeepEqual(tags, Tags{"linux", "dev"}) {
+		t.Fatalf("duplicates not removed: %v", tags)
+	}
+	tags = tags.Add("prod", "dev").Remove("linux").Replace("dev", "test")
+	if !reflect.DeepEqual(tags, Tags{"test", "prod"}) || !tags.HasAll("prod", "test") || tags.HasAny("dev") {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+	if normalised := (Tags{" Prod", "prod", "", "DEV "}).Normalise(); !reflect.DeepEqual(normalised, Tags{"prod", "dev"}) {
+		t.Fatalf("unexpected normalised tags: %v", normalised)
+	}
+	item := &Item{Key: "item_1"}
+	item.AddTags("b", "a")
+	item.RemoveTags("b")
+	if !reflect.DeepEqual(item.Tag, []interface{}{"a"}) || !item.HasTag("a") {
+		t.Fatalf("unexpected item tags: %v", item.Tag)
+	}
+}
+
+// checks that items are selected by type, tags and filter
+func TestItemQuery(t *testing.T) {
+	query := &ItemQuery{
+		Type:   "HOST",
+		Tags:   []string{"prod", "linux"},
+		Filter: func(item *Item) bool { return item.Status == 0 },
+	}
+	if uri := query.uri("http://localhost:8080"); uri != "http://localhost:8080/item?tag=prod%7Clinux&type=HOST" {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+	if match, _ := query.matches(&Item{Type: "HOST", Tag: []interface{}{"linux", "prod", "eu"}}); !match {
+		t.Fatal("expected item to match")
+	}
+	if match, _ := query.matches(&Item{Type: "HOST", Tag: []interface{}{"prod"}}); match {
+		t.Fatal("expected item without all tags not to match")
+	}
+	if match, _ := query.matches(&Item{Type: "HOST", Tag: []interface{}{"linux", "prod"}, Status: 1}); match {
+		t.Fatal("expected filtered item not to match")
+	}
+}
@@ -0,0 +1,348 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the layout of the timestamps used by the Web API (e.g. 01-01-2050 10:30:00+0100)
+const TimestampLayout = "02-01-2006 15:04:05-0700"
+
+// the layouts accepted when parsing timestamps and date attributes, the first one is used when formatting
+var timestampLayouts = []string{
+	TimestampLayout,
+	"02-01-2006 15:04:05",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parses a timestamp in any of the formats returned by the Web API or used in date attributes
+func ParseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp '%s'", value)
+}
+
+// formats a time in the layout used by the Web API
+func FormatTimestamp(t time.Time) string {
+	return t.Format(TimestampLayout)
+}
+
+// a time serialised in the layout used by the Web API, for use in custom types
+// the zero value is serialised as an empty string
+type Timestamp struct {
+	time.Time
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(FormatTimestamp(t.Time))
+}
+
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	parsed, err := parseOptionalTimestamp(value)
+	t.Time = parsed
+	return err
+}
+
+// parses a timestamp field, returning the zero time if the field is empty
+func parseOptionalTimestamp(value string) (time.Time, error) {
+	if len(strings.TrimSpace(value)) == 0 {
+		return time.Time{}, nil
+	}
+	return ParseTimestamp(value)
+}
+
+// the time the Item was created, the zero time if not set
+func (item *Item) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(item.Created)
+}
+
+// the time the Item was last updated, the zero time if not set
+func (item *Item) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(item.Updated)
+}
+
+// the time the ItemType was created, the zero time if not set
+func (itemType *ItemType) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(itemType.Created)
+}
+
+// the time the ItemType was last updated, the zero time if not set
+func (itemType *ItemType) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(itemType.Updated)
+}
+
+// the time the ItemTypeAttribute was created, the zero time if not set
+func (typeAttr *ItemTypeAttribute) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(typeAttr.Created)
+}
+
+// the time the ItemTypeAttribute was last updated, the zero time if not set
+func (typeAttr *ItemTypeAttribute) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(typeAttr.Updated)
+}
+
+// the time the Link was created, the zero time if not set
+func (link *Link) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(link.Created)
+}
+
+// the time the Link was last updated, the zero time if not set
+func (link *Link) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(link.Updated)
+}
+
+// the time the LinkRule was created, the zero time if not set
+func (rule *LinkRule) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(rule.Created)
+}
+
+// the time the LinkRule was last updated, the zero time if not set
+func (rule *LinkRule) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(rule.Updated)
+}
+
+// the time the LinkType was created, the zero time if not set
+func (linkType *LinkType) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(linkType.Created)
+}
+
+// the time the LinkType was last updated, the zero time if not set
+func (linkType *LinkType) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(linkType.Updated)
+}
+
+// the time the LinkTypeAttribute was created, the zero time if not set
+func (typeAttr *LinkTypeAttribute) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(typeAttr.Created)
+}
+
+// the time the LinkTypeAttribute was last updated, the zero time if not set
+func (typeAttr *LinkTypeAttribute) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(typeAttr.Updated)
+}
+
+// the time the Membership was created, the zero time if not set
+func (member *Membership) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(member.Created)
+}
+
+// the time the Membership was last updated, the zero time if not set
+func (member *Membership) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(member.Updated)
+}
+
+// the time the Model was created, the zero time if not set
+func (model *Model) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(model.Created)
+}
+
+// the time the Model was last updated, the zero time if not set
+func (model *Model) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(model.Updated)
+}
+
+// the time the Partition was created, the zero time if not set
+func (partition *Partition) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(partition.Created)
+}
+
+// the time the Partition was last updated, the zero time if not set
+func (partition *Partition) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(partition.Updated)
+}
+
+// the time the Privilege was created, the zero time if not set
+func (privilege *Privilege) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(privilege.Created)
+}
+
+// the time the Privilege was last updated, the zero time if not set
+func (privilege *Privilege) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(privilege.Updated)
+}
+
+// the time the Role was created, the zero time if not set
+func (role *Role) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(role.Created)
+}
+
+// the time the Role was last updated, the zero time if not set
+func (role *Role) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(role.Updated)
+}
+
+// the time the User was created, the zero time if not set
+func (user *User) CreatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(user.Created)
+}
+
+// the time the User was last updated, the zero time if not set
+func (user *User) UpdatedTime() (time.Time, error) {
+	return parseOptionalTimestamp(user.Updated)
+}
+
+// the time the user account expires, the zero time if not set
+func (user *User) ExpiresTime() (time.Time, error) {
+	return parseOptionalTimestamp(user.Expires)
+}
+
+// sets the time the user account expires
+func (user *User) SetExpires(t time.Time) {
+	user.Expires = FormatTimestamp(t)
+}
+
+// the time an item or link was last changed: its update time or, if never updated, its creation time
+func changedTime(created, updated string) (time.Time, error) {
+	if len(strings.TrimSpace(updated)) > 0 {
+		return ParseTimestamp(updated)
+	}
+	return parseOptionalTimestamp(created)
+}
+
+// sorts the items by creation time, oldest first
+// returns an error if a timestamp cannot be parsed, the list is then left unchanged
+func (list *ItemList) SortByCreated() error {
+	return sortByTime(len(list.Values), func(i int) (time.Time, error) { return list.Values[i].CreatedTime() }, func(i, j int) {
+		list.Values[i], list.Values[j] = list.Values[j], list.Values[i]
+	})
+}
+
+// sorts the items by the time they were last changed, oldest first
+// returns an error if a timestamp cannot be parsed, the list is then left unchanged
+func (list *ItemList) SortByUpdated() error {
+	return sortByTime(len(list.Values), func(i int) (time.Time, error) {
+		return changedTime(list.Values[i].Created, list.Values[i].Updated)
+	}, func(i, j int) {
+		list.Values[i], list.Values[j] = list.Values[j], list.Values[i]
+	})
+}
+
+// sorts the links by creation time, oldest first
+// returns an error if a timestamp cannot be parsed, the list is then left unchanged
+func (list *LinkList) SortByCreated() error {
+	return sortByTime(len(list.Values), func(i int) (time.Time, error) { return list.Values[i].CreatedTime() }, func(i, j int) {
+		list.Values[i], list.Values[j] = list.Values[j], list.Values[i]
+	})
+}
+
+// sorts the links by the time they were last changed, oldest first
+// returns an error if a timestamp cannot be parsed, the list is then left unchanged
+func (list *LinkList) SortByUpdated() error {
+	return sortByTime(len(list.Values), func(i int) (time.Time, error) {
+		return changedTime(list.Values[i].Created, list.Values[i].Updated)
+	}, func(i, j int) {
+		list.Values[i], list.Values[j] = list.Values[j], list.Values[i]
+	})
+}
+
+// sorts n values by time, parsing each timestamp once
+func sortByTime(n int, timeOf func(i int) (time.Time, error), swap func(i, j int)) error {
+	times := make([]time.Time, n)
+	for i := range times {
+		t, err := timeOf(i)
+		if err != nil {
+			return err
+		}
+		times[i] = t
+	}
+	sort.Stable(&timeSorter{times: times, swap: swap})
+	return nil
+}
+
+// sorts a list by a slice of times, swapping both together
+type timeSorter struct {
+	times []time.Time
+	swap  func(i, j int)
+}
+
+func (s *timeSorter) Len() int           { return len(s.times) }
+func (s *timeSorter) Less(i, j int) bool { return s.times[i].Before(s.times[j]) }
+func (s *timeSorter) Swap(i, j int) {
+	s.times[i], s.times[j] = s.times[j], s.times[i]
+	s.swap(i, j)
+}
+
+// a time interval, a zero From or To leaves the interval open on that side
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// true if the time is within the range, both ends included
+func (r TimeRange) Contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || !t.After(r.To))
+}
+
+// true if neither end of the range is set
+func (r TimeRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// gets the items created within the time range
+func (list *ItemList) CreatedWithin(r TimeRange) (*ItemList, error) {
+	result := &ItemList{}
+	for _, item := range list.Values {
+		t, err := item.CreatedTime()
+		if err != nil {
+			return nil, err
+		}
+		if r.Contains(t) {
+			result.Values = append(result.Values, item)
+		}
+	}
+	return result, nil
+}
+
+// gets the items last changed within the time range
+func (list *ItemList) UpdatedWithin(r TimeRange) (*ItemList, error) {
+	result := &ItemList{}
+	for _, item := range list.Values {
+		t, err := changedTime(item.Created, item.Updated)
+		if err != nil {
+			return nil, err
+		}
+		if r.Contains(t) {
+			result.Values = append(result.Values, item)
+		}
+	}
+	return result, nil
+}
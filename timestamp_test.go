@@ -0,0 +1,73 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// checks that the Web API timestamps are parsed and formatted
+func TestParseTimestamp(t *testing.T) {
+	user := &User{Expires: "01-01-2050 10:30:00+0100"}
+	expires, err := user.ExpiresTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expires.Equal(time.Date(2050, 1, 1, 9, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time: %s", expires)
+	}
+	user.SetExpires(expires)
+	if user.Expires != "01-01-2050 10:30:00+0100" {
+		t.Fatalf("unexpected format: %s", user.Expires)
+	}
+	if created, err := (&Item{}).CreatedTime(); err != nil || !created.IsZero() {
+		t.Fatalf("expected the zero time for an empty timestamp, got: %s %v", created, err)
+	}
+	var ts struct {
+		At Timestamp `json:"at"`
+	}
+	if err = json.Unmarshal([]byte(`{"at": "2020-06-01T09:00:00Z"}`), &ts); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(ts)
+	if string(b) != `{"at":"01-06-2020 09:00:00+0000"}` {
+		t.Fatalf("unexpected json: %s", b)
+	}
+}
+
+// checks that items are sorted and filtered by time
+func TestItemList_SortByUpdated(t *testing.T) {
+	list := &ItemList{Values: []Item{
+		{Key: "c", Created: "01-06-2020 09:00:00+0000", Updated: "05-06-2020 09:00:00+0000"},
+		{Key: "a", Created: "02-06-2020 09:00:00+0000"},
+		{Key: "b", Created: "01-06-2020 09:00:00+0000", Updated: "03-06-2020 09:00:00+0000"},
+	}}
+	if err := list.SortByUpdated(); err != nil {
+		t.Fatal(err)
+	}
+	if list.Values[0].Key != "a" || list.Values[1].Key != "b" || list.Values[2].Key != "c" {
+		t.Fatalf("unexpected order: %+v", list.Values)
+	}
+	recent, err := list.UpdatedWithin(TimeRange{From: time.Date(2020, 6, 3, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent.Values) != 2 {
+		t.Fatalf("expected 2 items, got: %+v", recent.Values)
+	}
+}
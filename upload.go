@@ -0,0 +1,174 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// the default maximum number of entities in an upload chunk
+const defaultChunkSize = 500
+
+// the default maximum size in bytes of the entities in an upload chunk
+const defaultChunkBytes = 4 << 20
+
+// options for uploading GraphData in chunks
+type UploadOptions struct {
+	// the maximum number of entities in a chunk, defaults to 500
+	ChunkSize int
+	// the maximum size in bytes of the JSON entities in a chunk, defaults to 4 MiB
+	// an entity larger than the limit is uploaded in a chunk of its own
+	ChunkBytes int
+	// the path of the file used to record the chunks uploaded, so that an interrupted upload can be resumed
+	// if empty, uploads cannot be resumed
+	Checkpoint string
+	// a function called after each chunk is uploaded
+	OnProgress func(progress UploadProgress)
+}
+
+// the progress of a chunked upload
+type UploadProgress struct {
+	// the number of chunks uploaded so far, including any uploaded before resuming
+	Chunk int
+	// the total number of chunks
+	Chunks int
+	// the number of entities uploaded so far, including any uploaded before resuming
+	Entities int
+	// the total number of entities
+	TotalEntities int
+	// true if the chunk was uploaded before the upload was resumed
+	Skipped bool
+}
+
+// the content of a checkpoint file
+type uploadCheckpoint struct {
+	// the hash of the data being uploaded, to make sure the checkpoint is for the same data
+	Fingerprint string `json:"fingerprint"`
+	// the maximum number of entities and bytes of the chunks the data was split into
+	ChunkSize  int `json:"chunkSize"`
+	ChunkBytes int `json:"chunkBytes"`
+	// the number of chunks uploaded
+	Completed int `json:"completed"`
+}
+
+// splits the graph data into chunks of at most size entities and maxBytes bytes of JSON entities
+// entities are kept in dependency order: models, item types, attributes, link types, link rules, items and then links
+func (data *GraphData) chunks(size, maxBytes int) ([]*GraphData, error) {
+	var (
+		chunks []*GraphData
+		chunk  = new(GraphData)
+		count  int
+		bytes  int
+	)
+	entities := data.entities()
+	for _, kind := range kindOrder {
+		for _, entity := range entities[kind] {
+			b, err := json.Marshal(entity)
+			if err != nil {
+				return nil, err
+			}
+			// starts a new chunk if the entity does not fit in the current one
+			if count > 0 && bytes+len(b) > maxBytes {
+				chunks = append(chunks, chunk)
+				chunk = new(GraphData)
+				count = 0
+				bytes = 0
+			}
+			chunk.add(entity)
+			count++
+			bytes += len(b)
+			if count == size {
+				chunks = append(chunks, chunk)
+				chunk = new(GraphData)
+				count = 0
+				bytes = 0
+			}
+		}
+	}
+	if count > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// adds an entity to the graph data
+func (data *GraphData) add(entity interface{}) {
+	switch e := entity.(type) {
+	case *Model:
+		data.Models = append(data.Models, *e)
+	case *ItemType:
+		data.ItemTypes = append(data.ItemTypes, *e)
+	case *ItemTypeAttribute:
+		data.ItemTypeAttributes = append(data.ItemTypeAttributes, *e)
+	case *LinkType:
+		data.LinkTypes = append(data.LinkTypes, *e)
+	case *LinkTypeAttribute:
+		data.LinkTypeAttribute = append(data.LinkTypeAttribute, *e)
+	case *LinkRule:
+		data.LinkRules = append(data.LinkRules, *e)
+	case *Item:
+		data.Items = append(data.Items, *e)
+	case *Link:
+		data.Links = append(data.Links, *e)
+	}
+}
+
+// the number of entities in the graph data
+func (data *GraphData) size() int {
+	return len(data.Models) + len(data.ItemTypes) + len(data.ItemTypeAttributes) + len(data.LinkTypes) +
+		len(data.LinkTypeAttribute) + len(data.LinkRules) + len(data.Items) + len(data.Links)
+}
+
+// a hash identifying the content of the graph data
+func (data *GraphData) fingerprint() (string, error) {
+	b, err := data.bytes()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(*b)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// reads a checkpoint file, returning an empty checkpoint if the file does not exist
+func readCheckpoint(path string) (*uploadCheckpoint, error) {
+	cp := new(uploadCheckpoint)
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, cp)
+	return cp, err
+}
+
+// writes a checkpoint file
+func (cp *uploadCheckpoint) save(path string) error {
+	content, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	// writes to a temporary file first, so that the checkpoint is not corrupted if the process is interrupted
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,189 @@
+/*
+   Onix Configuration Manager - Web Api go client
+   Copyright (c) 2018-2020 by www.gatblau.org
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software distributed under
+   the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+   either express or implied.
+   See the License for the specific language governing permissions and limitations under the License.
+
+   Contributors to this project, hereby assign copyright in this code to the project,
+   to be licensed under the same terms as the rest of the code.
+*/
+package oxc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// graph data with a model, an item type and the specified number of items
+func uploadData(items int) *GraphData {
+	data := &GraphData{
+		Models:    []Model{{Key: "m"}},
+		ItemTypes: []ItemType{{Key: "t", Model: "m"}},
+	}
+	for i := 0; i < items; i++ {
+		data.Items = append(data.Items, Item{Key: fmt.Sprintf("item_%d", i), Type: "t"})
+	}
+	data.Links = []Link{{Key: "link", StartItemKey: "item_0", EndItemKey: "item_1"}}
+	return data
+}
+
+// checks that chunks are bounded in size and keep the dependency order
+func TestGraphData_Chunks(t *testing.T) {
+	data := uploadData(5)
+	chunks, err := data.chunks(3, defaultChunkBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	var keys []string
+	for i, chunk := range chunks {
+		expected := 3
+		if i == len(chunks)-1 {
+			expected = 2
+		}
+		if chunk.size() != expected {
+			t.Fatalf("chunk %d: expected %d entities, got %d", i+1, expected, chunk.size())
+		}
+		for _, kind := range kindOrder {
+			for _, entity := range chunk.entities()[kind] {
+				keys = append(keys, kind+":"+entityKey(entity))
+			}
+		}
+	}
+	expected := "[model:m itemType:t item:item_0 item:item_1 item:item_2 item:item_3 item:item_4 link:link]"
+	if fmt.Sprint(keys) != expected {
+		t.Fatalf("expected %s, got %v", expected, keys)
+	}
+	if chunks, _ := data.chunks(data.size(), defaultChunkBytes); len(chunks) != 1 {
+		t.Fatalf("expected a single chunk, got %d", len(chunks))
+	}
+	if chunks, _ := new(GraphData).chunks(3, defaultChunkBytes); len(chunks) != 0 {
+		t.Fatalf("expected no chunks, got %d", len(chunks))
+	}
+}
+
+// checks that chunks are bounded in bytes and that large entities are sent on their own
+func TestGraphData_ChunkBytes(t *testing.T) {
+	data := uploadData(4)
+	data.Items[1].Meta = map[string]interface{}{"blob": strings.Repeat("x", 2000)}
+	itemBytes, _ := json.Marshal(&data.Items[0])
+	// a limit fitting three small items
+	chunks, err := data.chunks(100, 3*len(itemBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	for _, chunk := range chunks {
+		sizes = append(sizes, chunk.size())
+	}
+	// model, item type and item_0 | the large item_1 | item_2, item_3 and the link
+	if fmt.Sprint(sizes) != "[3 1 3]" {
+		t.Fatalf("unexpected chunk sizes: %v", sizes)
+	}
+	if len(chunks[1].Items) != 1 || chunks[1].Items[0].Key != "item_1" {
+		t.Fatalf("expected the large item in a chunk of its own, got: %+v", chunks[1])
+	}
+}
+
+// checks that an interrupted upload records a checkpoint and resumes from it
+func TestClient_PutDataChunked(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := filepath.Join(dir, "upload.json")
+	// the fourth item cannot be written, so the second chunk fails
+	data := uploadData(6)
+	data.Items[3].Key = "bad"
+	err = c.PutDataChunked(data, &UploadOptions{ChunkSize: 3, Checkpoint: checkpoint})
+	if err == nil {
+		t.Fatal("expected the upload to fail")
+	}
+	cp, err := readCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, _ := data.fingerprint()
+	if cp.Completed != 1 || cp.ChunkSize != 3 || cp.ChunkBytes != defaultChunkBytes || cp.Fingerprint != fingerprint {
+		t.Fatalf("unexpected checkpoint: %+v", cp)
+	}
+	// fixes the item and records the checkpoint for the fixed data, so that the upload resumes after the first chunk
+	delete(items, "item_0")
+	data.Items[3].Key = "item_3"
+	cp.Fingerprint, _ = data.fingerprint()
+	if err = cp.save(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	var progress []UploadProgress
+	err = c.PutDataChunked(data, &UploadOptions{
+		ChunkSize:  3,
+		Checkpoint: checkpoint,
+		OnProgress: func(p UploadProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := items["item_0"]; exists {
+		t.Fatal("the chunk uploaded before resuming was uploaded again")
+	}
+	if len(items) != 5 {
+		t.Fatalf("expected 5 items, got %d", len(items))
+	}
+	if len(progress) != 3 || !progress[0].Skipped || progress[1].Skipped || progress[2].Entities != data.size() {
+		t.Fatalf("unexpected progress: %+v", progress)
+	}
+	// the checkpoint is removed when the upload completes
+	if _, err = os.Stat(checkpoint); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be removed, got: %v", err)
+	}
+}
+
+// checks that a checkpoint recorded for different data is discarded
+func TestClient_PutDataChunked_Fingerprint(t *testing.T) {
+	server, items := fakeItemServer()
+	defer server.Close()
+	c, err := NewClient(&ClientConf{BaseURI: server.URL, AuthMode: None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := filepath.Join(dir, "upload.json")
+	cp := &uploadCheckpoint{Fingerprint: "other", ChunkSize: 3, ChunkBytes: defaultChunkBytes, Completed: 2}
+	if err = cp.save(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	data := uploadData(6)
+	if err = c.PutDataChunked(data, &UploadOptions{ChunkSize: 3, Checkpoint: checkpoint}); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 6 {
+		t.Fatalf("expected all 6 items to be uploaded, got %d", len(items))
+	}
+	if _, err = os.Stat(checkpoint); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be removed, got: %v", err)
+	}
+}